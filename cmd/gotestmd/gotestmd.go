@@ -18,171 +18,104 @@
 package gotestmd
 
 import (
-	"os"
-	"path"
-	"path/filepath"
-	"regexp"
-	"strings"
-
-	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
-	"github.com/networkservicemesh/gotestmd/internal/config"
-	"github.com/networkservicemesh/gotestmd/internal/generator"
-	"github.com/networkservicemesh/gotestmd/internal/linker"
-	"github.com/networkservicemesh/gotestmd/internal/parser"
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+	"github.com/networkservicemesh/gotestmd/pkg/pipeline"
 )
 
 // New creates new cmd/gotestmd
 func New() *cobra.Command {
 	gotestmdCmd := &cobra.Command{
-		Use:     "gotestmd",
-		Short:   "Command for generating integration tests",
+		Use:   "gotestmd INPUT_DIR OUTPUT_DIR [BASE_PKG]",
+		Short: "Command for generating integration tests",
+		Long: `Generates integration tests from markdown examples in INPUT_DIR to OUTPUT_DIR.
+
+BASE_PKG is a package of the base suite for generated suites. Its Suite type must embed shell.Suite
+of github.com/networkservicemesh/gotestmd/pkg/suites/shell: generated suites call its methods.`,
 		Version: "0.0.1",
+		Args:    cobra.ArbitraryArgs,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			match := cmd.Flag("match").Value.String()
-			bash := false
-			if value, err := cmd.Flags().GetBool("bash"); err == nil {
-				bash = value
-			}
-			retry := false
-			if value, err := cmd.Flags().GetBool("retry"); err == nil {
-				retry = value
-			}
-
-			if bash && match == "" {
-				return errors.New("Flag --bash can be used only with flag --match")
-			}
-
-			c := config.FromArgs(args)
-			c.Bash = bash
-			c.Match = match
-			_ = os.MkdirAll(c.OutputDir, os.ModePerm)
-			var examples []*parser.Example
-
-			var p = parser.New()
-			var l = linker.New(c.InputDir)
-			var g = generator.New(c)
-			dirs := getRecursiveDirectories(c.InputDir)
-			for _, dir := range dirs {
-				ex, err := p.ParseFile(path.Join(dir, "README.md"))
-				if err == nil {
-					examples = append(examples, ex)
-				}
-			}
-			linkedExamples, err := l.Link(examples...)
-			if err != nil {
-				return errors.Errorf("cannot build examples: %v", err.Error())
-			}
-
-			suites := g.Generate(linkedExamples...)
-
-			if !bash {
-				return processGoSuites(suites)
-			}
-
-			matchRegex, err := regexp.Compile(match)
+			c, err := loadConfig(cmd, args)
 			if err != nil {
 				return err
 			}
-
-			return processBashSuites(suites, matchRegex, retry)
+			return pipeline.New(c).Run()
 		},
 	}
 
-	gotestmdCmd.Flags().Bool("bash", false, "generates bash scripts for tests. Can be used only with --match flag")
-	gotestmdCmd.Flags().String("match", "", "regex for matching suite or test name. Can be used only with --bash flag")
+	addConfigFlags(gotestmdCmd)
+	gotestmdCmd.Flags().Bool("bash", false, "generates bash scripts for tests. Can be used only with --match, --tags or --exclude-tags flags")
+	gotestmdCmd.Flags().Bool("make", false, "generates a Makefile with setup, test and cleanup targets for each suite and test")
 	gotestmdCmd.Flags().Bool("retry", false, "add retry to commands in generated bash scripts. Does not affect golang tests")
+	gotestmdCmd.Flags().Bool("entry-point", false, "generates entry_point_test.go in the output dir that runs all top-level suites")
+	gotestmdCmd.Flags().String("build-tags", "", "build constraint for generated go suites, e.g. \"integration && linux\"")
+
+	gotestmdCmd.AddCommand(newMatrixCommand(), newReportCommand())
 
 	return gotestmdCmd
 }
 
-func processGoSuites(suites []*generator.Suite) error {
-	for _, suite := range suites {
-		dir, _ := filepath.Split(suite.Location)
-		_ = os.MkdirAll(dir, os.ModePerm)
-		err := os.WriteFile(suite.Location, []byte(suite.String()), os.ModePerm)
-		if err != nil {
-			return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
-		}
-	}
-
-	return nil
+// addConfigFlags adds flags that select the config and the examples. They are used by the root and matrix commands
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().String("config", "", "path to the config file. By default "+config.FileName+" is looked up in the input dir")
+	cmd.Flags().String("match", "", "regex for matching suite or test name. Only matching suites, tests and the suites they require are generated")
+	cmd.Flags().String("tags", "", "expression over front matter labels for selecting examples, e.g. \"calico && !ipv6\"")
+	cmd.Flags().String("exclude-tags", "", "expression over front matter labels for excluding examples")
+	cmd.Flags().StringSlice("roots", nil, "paths of top-level suites to run from the entry point, e.g. producer/consumer2. Runs all top-level suites by default")
 }
 
-func processBashSuites(suites []*generator.Suite, matchRegex *regexp.Regexp, retry bool) error {
-	matchFound := false
+func loadConfig(cmd *cobra.Command, args []string) (config.Config, error) {
+	var c = config.Default()
 
-	for _, suite := range suites {
-		if !matchRegex.MatchString(suite.Name()) {
-			continue
-		}
-		matchFound = true
-		suite.Tests = nil
-		dir, _ := filepath.Split(suite.Location)
-		_ = os.MkdirAll(dir, os.ModePerm)
-		err := os.WriteFile(suite.Location, []byte(suite.BashString(retry)), os.ModePerm)
-		if err != nil {
-			return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
-		}
+	configPath := cmd.Flag("config").Value.String()
+	if configPath == "" && len(args) > 0 {
+		configPath = config.Find(args[0])
 	}
-
-	for _, suite := range suites {
-		matchedTests := make([]*generator.Test, 0)
-		for _, test := range suite.Tests {
-			if matchRegex.MatchString(test.Name) {
-				matchedTests = append(matchedTests, test)
-				matchFound = true
-			}
-		}
-		if len(matchedTests) == 0 {
-			continue
-		}
-
-		suite.Tests = matchedTests
-		dir, _ := filepath.Split(suite.Location)
-		_ = os.MkdirAll(dir, os.ModePerm)
-		err := os.WriteFile(suite.Location, []byte(suite.BashString(retry)), os.ModePerm)
-		if err != nil {
-			return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
+	if configPath != "" {
+		var err error
+		if c, err = config.Load(configPath); err != nil {
+			return c, err
 		}
 	}
 
-	if !matchFound {
-		return errors.Errorf("No matches found for pattern: %s", matchRegex.String())
+	if err := c.ApplyArgs(args); err != nil {
+		return c, err
 	}
 
-	return nil
-}
-
-func getFilter(root string) func(string) bool {
-	var ignored []string
-	ignored = append(ignored, filepath.Join(root, ".git"))
-
-	return func(s string) bool {
-		for _, line := range ignored {
-			if strings.HasPrefix(s, line) {
-				return true
-			}
-		}
-		return false
+	flags := cmd.Flags()
+	var targets []string
+	if bash, _ := flags.GetBool("bash"); bash {
+		targets = append(targets, config.TargetBash)
+	}
+	if makefile, _ := flags.GetBool("make"); makefile {
+		targets = append(targets, config.TargetMake)
+	}
+	if len(targets) > 0 {
+		c.Targets = targets
+	}
+	if flags.Changed("match") {
+		c.Match, _ = flags.GetString("match")
+	}
+	if flags.Changed("tags") {
+		c.Tags, _ = flags.GetString("tags")
+	}
+	if flags.Changed("exclude-tags") {
+		c.ExcludeTags, _ = flags.GetString("exclude-tags")
+	}
+	if flags.Changed("retry") {
+		c.Retry.Enabled, _ = flags.GetBool("retry")
+	}
+	if flags.Changed("entry-point") {
+		c.EntryPoint, _ = flags.GetBool("entry-point")
+	}
+	if flags.Changed("roots") {
+		c.Roots, _ = flags.GetStringSlice("roots")
+	}
+	if flags.Changed("build-tags") {
+		c.BuildTags, _ = flags.GetString("build-tags")
 	}
-}
-
-func getRecursiveDirectories(root string) []string {
-	var result []string
-	var isIgnored = getFilter(root)
-	_ = filepath.Walk(root,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() && !isIgnored(path) {
-				result = append(result, path)
-			}
-			return nil
-		})
 
-	return result
+	return c, c.Validate()
 }
@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotestmd
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/networkservicemesh/gotestmd/pkg/pipeline"
+)
+
+func newMatrixCommand() *cobra.Command {
+	matrixCmd := &cobra.Command{
+		Use:   "matrix INPUT_DIR OUTPUT_DIR [BASE_PKG]",
+		Short: "Prints a JSON matrix of top-level suites for a CI strategy.matrix",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			matrix, err := pipeline.New(c).Matrix()
+			if err != nil {
+				return err
+			}
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			if indent, _ := cmd.Flags().GetBool("indent"); indent {
+				encoder.SetIndent("", "  ")
+			}
+			return errors.Wrap(encoder.Encode(matrix), "cannot encode matrix")
+		},
+	}
+
+	addConfigFlags(matrixCmd)
+	matrixCmd.Flags().Bool("indent", false, "prints indented JSON")
+
+	return matrixCmd
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotestmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+func newReportCommand() *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report REPORT_JSON...",
+		Short: "Prints the slowest and the flakiest steps of each example from JSON reports",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var steps []*report.Step
+			for _, path := range args {
+				r, err := readReport(path)
+				if err != nil {
+					return err
+				}
+				steps = append(steps, r.Steps...)
+			}
+			top, _ := cmd.Flags().GetInt("top")
+			return report.WriteTable(cmd.OutOrStdout(), report.Aggregate(steps...), top)
+		},
+	}
+
+	reportCmd.Flags().Int("top", 5, "number of steps in each table. 0 prints all steps")
+
+	return reportCmd
+}
+
+func readReport(path string) (*report.Report, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open report %v", path)
+	}
+	defer func() { _ = f.Close() }()
+	r, err := report.ReadJSON(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read report %v", path)
+	}
+	return r, nil
+}
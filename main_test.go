@@ -19,7 +19,9 @@
 package main_test
 
 import (
+	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -71,6 +73,552 @@ EOF
 	require.Zero(t, exitCode)
 }
 
+func TestEntryPoint(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-entry-point")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-entry-point/ --entry-point --roots=helloworld,tree,producer/consumer2")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	content, err := os.ReadFile("test-entry-point/entry_point_test.go")
+	require.NoError(t, err)
+	require.Contains(t, string(content), `t.Run("producer/consumer2"`)
+	require.NotContains(t, string(content), `t.Run("producer/consumer3"`)
+
+	stdout, _, exitCode, err := runner.Run("go test -v ./test-entry-point/")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "TestEntryPoint/helloworld")
+	require.Contains(t, stdout, "TestEntryPoint/tree")
+	require.Contains(t, stdout, "TestEntryPoint/producer/consumer2")
+}
+
+func TestSharedSetup(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-shared-setup")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-shared-setup/ --entry-point --roots=producer/consumer2,producer/consumer3")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("go test -count=1 -v ./test-shared-setup/ 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stdout)
+	require.Equal(t, 1, strings.Count(stdout, "msg=Do setup logic for the suite here TestEntryPoint/producer/consumer2=stdout"))
+	require.NotContains(t, stdout, "msg=Do setup logic for the suite here TestEntryPoint/producer/consumer3=stdout")
+	// the producer is cleaned up after both consumers
+	teardown := strings.Index(stdout, "msg=Do teardown logic for the suite here TestEntryPoint=stdout")
+	require.Greater(t, teardown, strings.LastIndex(stdout, "TestEntryPoint/producer/consumer3=stdout"))
+}
+
+func TestCustomBasePkg(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-custom-base")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run(`mkdir -p test-custom-base/custombase && cat > test-custom-base/custombase/suite.go <<EOF
+package custombase
+
+import "github.com/networkservicemesh/gotestmd/pkg/suites/shell"
+
+type Suite struct {
+	shell.Suite
+}
+EOF
+`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-custom-base/suites/ github.com/networkservicemesh/gotestmd/test-custom-base/custombase --entry-point --roots=producer/consumer2,producer/consumer3")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	// the entry point of a custom base package shares setup via the shell package
+	stdout, _, exitCode, err := runner.Run("go vet ./test-custom-base/... 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stdout)
+	bytes, err := os.ReadFile("test-custom-base/suites/entry_point_test.go")
+	require.NoError(t, err)
+	require.Contains(t, string(bytes), "shell.ShareSetup(t)")
+}
+
+func TestBuildTags(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-build-tags")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run(`gotestmd examples/ test-build-tags/ --entry-point --roots=helloworld --build-tags="gotestmd_integration"`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	content, err := os.ReadFile("test-build-tags/helloworld/suite.gen.go")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "//go:build gotestmd_integration\n\n// Package helloworld contains a suite generated from examples/HelloWorld/README.md\npackage helloworld")
+
+	stdout, _, exitCode, err := runner.Run("go list ./test-build-tags/...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.NotContains(t, stdout, "test-build-tags/helloworld")
+
+	stdout, _, exitCode, err = runner.Run("go test -v -tags gotestmd_integration ./test-build-tags/")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "TestEntryPoint/helloworld")
+}
+
+func TestExampleBuildTags(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-example-build-tags")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	example := func(dir, content string) string {
+		return `mkdir -p test-example-build-tags/examples/` + dir + ` && cat > test-example-build-tags/examples/` + dir + `/README.md <<'EOF'
+` + content + `
+# Run
+
+` + "```bash" + `
+echo ` + dir + `
+` + "```" + `
+EOF
+`
+	}
+	const tags = "---\nbuild-tags: gotestmd_example\n---\n"
+	for _, script := range []string{
+		example("Root", "# Includes\n\n- [Leaf](./Leaf)\n- [Child](./Child)\n"),
+		example("Root/Leaf", tags),
+		example("Root/Child", tags+"# Includes\n\n- [Step](./Step)\n"),
+		example("Root/Child/Step", ""),
+		example("Setup", tags),
+		example("Consumer", "# Requires\n\n- [Setup](../Setup)\n"),
+	} {
+		_, _, exitCode, err = runner.Run(script)
+		require.NoError(t, err)
+		require.Zero(t, exitCode)
+	}
+
+	_, stderr, exitCode, err := runner.Run("gotestmd test-example-build-tags/examples/ test-example-build-tags/suites/ --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stderr)
+
+	// tags of the tagged examples are not pushed to the suites using them
+	for _, file := range []string{"root/suite.gen.go", "consumer/suite.gen.go", "entry_point_test.go"} {
+		content, err := os.ReadFile("test-example-build-tags/suites/" + file)
+		require.NoError(t, err)
+		require.NotContains(t, string(content), "//go:build", file)
+	}
+
+	stdout, _, exitCode, err := runner.Run("go test -count=1 -v ./test-example-build-tags/suites/ 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stdout)
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/root")
+	require.Contains(t, stdout, "--- SKIP: TestEntryPoint/root/TestLeaf")
+	require.Contains(t, stdout, "--- SKIP: TestEntryPoint/root/Child")
+	require.Contains(t, stdout, "--- SKIP: TestEntryPoint/consumer")
+
+	stdout, _, exitCode, err = runner.Run("go test -count=1 -v -tags gotestmd_example ./test-example-build-tags/suites/ 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stdout)
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/root/TestLeaf")
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/root/Child/TestStep")
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/consumer")
+}
+
+func TestGoMatch(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-go-match")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-go-match/ --match=consumer2 --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("go list ./test-go-match/...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "test-go-match/producer/consumer2")
+	require.Contains(t, stdout, "test-go-match/producer\n")
+	require.NotContains(t, stdout, "test-go-match/helloworld")
+
+	stdout, _, exitCode, err = runner.Run("go test -v ./test-go-match/")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "TestEntryPoint/producer/consumer2")
+}
+
+func TestMatrix(t *testing.T) {
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("gotestmd matrix examples/ test-examples/ --roots=tree,helloworld")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	var matrix struct {
+		Include []map[string]interface{} `json:"include"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &matrix))
+	require.Len(t, matrix.Include, 2)
+	require.Equal(t, "helloworld", matrix.Include[0]["suite"])
+	require.Equal(t, "github.com/networkservicemesh/gotestmd/test-examples/tree", matrix.Include[1]["package"])
+
+	_, err = os.Stat("test-examples")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestReport(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-report")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-report/ --match=helloworld --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("go test ./test-report/ -args -gotestmd.report.json=$PWD/test-report/report.json -gotestmd.report.junit=$PWD/test-report/junit.xml")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	content, err := os.ReadFile("test-report/report.json")
+	require.NoError(t, err)
+	var report struct {
+		Steps []map[string]interface{} `json:"steps"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	require.Len(t, report.Steps, 2)
+	require.Equal(t, "examples/HelloWorld/README.md:10", report.Steps[0]["location"])
+	require.Equal(t, "examples/HelloWorld/README.md:17", report.Steps[1]["location"])
+
+	content, err = os.ReadFile("test-report/junit.xml")
+	require.NoError(t, err)
+	require.Contains(t, string(content), `<testsuite name="TestEntryPoint/helloworld" tests="2" failures="0"`)
+
+	stdout, _, exitCode, err := runner.Run("gotestmd report test-report/report.json test-report/report.json")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "examples/HelloWorld/README.md:10  2 ")
+
+	// generation flags are not accepted by the report command
+	_, stderr, exitCode, err := runner.Run("gotestmd report --match=helloworld test-report/report.json")
+	require.NoError(t, err)
+	require.NotZero(t, exitCode)
+	require.Contains(t, stderr, "unknown flag: --match")
+}
+
+func TestRetries(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-retries")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-retries/ --match=retry --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("go test -v -count=1 ./test-retries/ -args -gotestmd.report.json=$PWD/test-retries/report.json 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "steps succeeded after retries:\nexamples/Retry/README.md:15 (2 attempts): [ -f retry-file-flag ] || (")
+
+	content, err := os.ReadFile("test-retries/report.json")
+	require.NoError(t, err)
+	var report struct {
+		Steps []struct {
+			Attempts int `json:"attempts"`
+			History  []struct {
+				ExitCode int `json:"exit-code"`
+			} `json:"history"`
+		} `json:"steps"`
+	}
+	require.NoError(t, json.Unmarshal(content, &report))
+	require.Equal(t, 2, report.Steps[1].Attempts)
+	require.Len(t, report.Steps[1].History, 2)
+	require.Equal(t, 1, report.Steps[1].History[0].ExitCode)
+	require.Equal(t, 0, report.Steps[1].History[1].ExitCode)
+
+	stdout, _, exitCode, err = runner.Run("go test -count=1 ./test-retries/ -args -gotestmd.max-attempts=1 2>&1")
+	require.NoError(t, err)
+	require.NotZero(t, exitCode)
+	require.Contains(t, stdout, "step examples/Retry/README.md:15 succeeded after 2 attempts, but -gotestmd.max-attempts is 1")
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-diagnostics")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run(`mkdir -p test-diagnostics/examples/Failing && cat > test-diagnostics/examples/Failing/README.md <<'EOF'
+---
+retry:
+  attempts: 1
+---
+# Run
+
+` + "```bash" + `
+export STATE=broken
+` + "```" + `
+
+` + "```bash" + `
+false
+` + "```" + `
+
+# Cleanup
+
+` + "```bash" + `
+unset STATE
+` + "```" + `
+
+# OnFailure
+
+` + "```bash" + `
+echo "state is $STATE"
+` + "```" + `
+EOF
+`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd test-diagnostics/examples/ test-diagnostics/suites/ --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("go test -count=1 ./test-diagnostics/suites/ -args -gotestmd.artifacts=$PWD/test-diagnostics/artifacts")
+	require.NoError(t, err)
+	require.NotZero(t, exitCode)
+
+	content, err := os.ReadFile("test-diagnostics/artifacts/TestEntryPoint/failing/diagnostics-1.log")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "# stdout\nstate is broken\n")
+}
+
+func TestParallel(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-parallel")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	// rendezvous waits until the other example is started, so the example fails if they are run one by one
+	rendezvous := func(dir, name, other, includes string) string {
+		return `mkdir -p test-parallel/examples/` + dir + ` && cat > test-parallel/examples/` + dir + `/README.md <<'EOF'
+---
+parallel: true
+retry:
+  attempts: 1
+---
+` + includes + `
+# Run
+
+` + "```bash" + `
+touch "$PARALLEL_DIR/` + name + `"
+for i in $(seq 100); do [ -f "$PARALLEL_DIR/` + other + `" ] && break; sleep 0.1; done
+[ -f "$PARALLEL_DIR/` + other + `" ]
+` + "```" + `
+EOF
+`
+	}
+	// consumer requires a suite that can't be set up twice at the same time
+	consumer := func(dir string) string {
+		return `mkdir -p test-parallel/examples/` + dir + ` && cat > test-parallel/examples/` + dir + `/README.md <<'EOF'
+---
+parallel: true
+---
+# Requires
+
+- [Shared](../Shared)
+
+# Run
+
+` + "```bash" + `
+echo "consumer ` + dir + `"
+` + "```" + `
+EOF
+`
+	}
+	for _, script := range []string{
+		"export PARALLEL_DIR=$(mktemp -d)",
+		rendezvous("A", "a", "b", "# Includes\n\n- [X](./X)\n- [Y](./Y)\n"),
+		rendezvous("A/X", "x", "y", ""),
+		rendezvous("A/Y", "y", "x", ""),
+		rendezvous("B", "b", "a", ""),
+		consumer("C"),
+		consumer("D"),
+		`mkdir -p test-parallel/examples/Shared && cat > test-parallel/examples/Shared/README.md <<'EOF'
+# Run
+
+` + "```bash" + `
+mkdir "$PARALLEL_DIR/shared"
+` + "```" + `
+
+# Cleanup
+
+` + "```bash" + `
+rmdir "$PARALLEL_DIR/shared"
+` + "```" + `
+EOF
+`,
+	} {
+		_, _, exitCode, err = runner.Run(script)
+		require.NoError(t, err)
+		require.Zero(t, exitCode)
+	}
+
+	_, stderr, exitCode, err := runner.Run("gotestmd test-parallel/examples/ test-parallel/suites/ --entry-point")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stderr)
+
+	content, err := os.ReadFile("test-parallel/suites/entry_point_test.go")
+	require.NoError(t, err)
+	require.Equal(t, 2, strings.Count(string(content), "branches[0].Lock()"))
+
+	stdout, _, exitCode, err := runner.Run("go test -race -count=1 -v -parallel=4 ./test-parallel/suites/ 2>&1")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stdout)
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/a/TestX")
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/b")
+	require.Contains(t, stdout, "--- PASS: TestEntryPoint/d")
+}
+
+func TestConfig(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-config-examples")
+		_ = os.Remove("test-gotestmd.yaml")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run(`cat > test-gotestmd.yaml <<EOF
+input-dir: examples
+output-dir: test-config-examples
+targets: [bash]
+match: tree
+retry:
+  enabled: true
+EOF
+`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd --config test-gotestmd.yaml")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	content, err := os.ReadFile("test-config-examples/tree/suite.gen.sh")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "try_run")
+
+	_, _, exitCode, err = runner.Run(`echo "target: bash" >> test-gotestmd.yaml`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, stderr, exitCode, err := runner.Run("gotestmd --config test-gotestmd.yaml")
+	require.NoError(t, err)
+	require.NotZero(t, exitCode)
+	require.Contains(t, stderr, "cannot parse config")
+}
+
+func TestConfigRelativePaths(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-config-dir")
+		_ = os.RemoveAll("test-config-relative-examples")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	// paths are relative to the config file
+	_, _, exitCode, err = runner.Run(`mkdir -p test-config-dir && cat > test-config-dir/gotestmd.yaml <<EOF
+input-dir: ../examples
+output-dir: ../test-config-relative-examples
+targets: [bash]
+match: tree
+templates:
+  bash-suite: suite.sh.tmpl
+EOF
+echo '# custom suite {{ .Dir }}' > test-config-dir/suite.sh.tmpl
+`)
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, stderr, exitCode, err := runner.Run("gotestmd --config test-config-dir/gotestmd.yaml")
+	require.NoError(t, err)
+	require.Zero(t, exitCode, stderr)
+
+	content, err := os.ReadFile("test-config-relative-examples/tree/suite.gen.sh")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "# custom suite")
+}
+
 func TestBashSuite(t *testing.T) {
 	t.Cleanup(func() {
 		_ = os.RemoveAll("test-bash-examples")
@@ -123,6 +671,67 @@ func TestBashTest(t *testing.T) {
 	require.Zero(t, exitCode)
 }
 
+func TestBashCLI(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-bash-examples")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-bash-examples/ --bash --match=LeafA")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("./test-bash-examples/tree/suite.gen.sh --help")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "Usage: suite.gen.sh COMMAND")
+
+	stdout, _, exitCode, err = runner.Run("./test-bash-examples/tree/suite.gen.sh list")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Equal(t, "testLeafA", stdout)
+
+	stdout, _, exitCode, err = runner.Run("./test-bash-examples/tree/suite.gen.sh all")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "setup suite test-bash-examples/tree")
+	require.Contains(t, stdout, "cleanup suite test-bash-examples/tree")
+
+	_, stderr, exitCode, err := runner.Run("./test-bash-examples/tree/suite.gen.sh testLeafB")
+	require.NoError(t, err)
+	require.NotZero(t, exitCode)
+	require.Contains(t, stderr, "unknown command: testLeafB")
+}
+
+func TestMakefile(t *testing.T) {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("test-make-examples")
+	})
+	runner, err := bash.New()
+	require.NoError(t, err)
+	defer runner.Close()
+	_, _, exitCode, err := runner.Run("go install ./...")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	_, _, exitCode, err = runner.Run("gotestmd examples/ test-make-examples/ --make")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	stdout, _, exitCode, err := runner.Run("make -C test-make-examples test-tree/TestLeafA cleanup-tree")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Contains(t, stdout, "setup suite tree")
+	require.Contains(t, stdout, "I'm leaf A")
+	require.Contains(t, stdout, "cleanup suite tree/subtree")
+	require.Contains(t, stdout, "cleanup suite tree")
+}
+
 func TestBashRetry(t *testing.T) {
 	t.Cleanup(func() {
 		_ = os.RemoveAll("test-bash-examples")
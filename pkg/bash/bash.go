@@ -25,14 +25,21 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 const (
 	initialBufferSize    = 1 << 10
 	finishMessage        = "gotestmd/pkg/suites/shell/Bash.const.finish"
+	varsMessage          = "gotestmd/pkg/suites/shell/Bash.const.vars"
 	cmdPrintStatusCode   = `echo -e \\n$?`
 	cmdPrintStdoutFinish = `echo ` + finishMessage
 	cmdPrintStderrFinish = cmdPrintStdoutFinish + ` >&2`
+	defaultGracePeriod   = 5 * time.Second
+	stopPollInterval     = 10 * time.Millisecond
 )
 
 // Bash is api for bash process
@@ -40,8 +47,17 @@ type Bash struct {
 	dir       string
 	env       []string
 	resources []io.Closer
-	ctx       context.Context
-	cancel    context.CancelFunc
+	// gracePeriod is a time processes have to exit after they are asked to
+	gracePeriod time.Duration
+	// groups contains jobs started in their own process groups
+	groups   map[int]struct{}
+	groupsMu sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	// varNames are names of the variables that are read with the exit code of each command
+	varNames []string
+	vars     map[string]string
 
 	cmd *exec.Cmd
 
@@ -52,7 +68,9 @@ type Bash struct {
 
 // New creates a new bash runner and initializes it
 func New(options ...Option) (*Bash, error) {
-	b := &Bash{}
+	b := &Bash{
+		gracePeriod: defaultGracePeriod,
+	}
 	for _, o := range options {
 		o(b)
 	}
@@ -65,19 +83,137 @@ func New(options ...Option) (*Bash, error) {
 	return b, nil
 }
 
-// Close closes current bash process and all the resources used by it
+// Close closes current bash process and all the resources used by it.
+// If bash doesn't exit during the grace period, for example, because a command hangs, it is terminated.
+// Processes left in the bash process group, e.g. background jobs, are terminated too.
 func (b *Bash) Close() {
 	b.cancel()
-	_, err := b.stdin.Write([]byte("exit 0\n"))
-	if err != nil {
-		panic(err)
+	// the error means that bash has already exited
+	_, _ = b.stdin.Write([]byte("exit 0\n"))
+
+	done := make(chan struct{})
+	go func() {
+		_ = b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(b.gracePeriod):
 	}
-	_ = b.cmd.Wait()
+	b.stop(processGroup(b.cmd.Process.Pid))
+	<-done
+	for _, pid := range b.startedJobs() {
+		b.StopJob(pid)
+	}
+
 	for _, r := range b.resources {
 		_ = r.Close()
 	}
 }
 
+// Jobs returns process ids of the background jobs started by the commands
+func (b *Bash) Jobs() ([]int, error) {
+	stdout, stderr, exitCode, err := b.Run("jobs -p")
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, errors.Errorf("cannot list jobs: %v", stderr)
+	}
+	var result []int
+	for _, field := range strings.Fields(stdout) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid job pid %v", field)
+		}
+		result = append(result, pid)
+	}
+	return result, nil
+}
+
+// KillJobs terminates the background jobs. Jobs that are still running after the grace period are killed
+func (b *Bash) KillJobs() error {
+	pids, err := b.Jobs()
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if b.started(pid) {
+			b.StopJob(pid)
+			continue
+		}
+		b.stop(pid)
+	}
+	// wait removes finished jobs from the jobs list
+	_, _, _, err = b.Run("wait")
+	return err
+}
+
+// Start starts the command as a background job in its own process group and returns the job pid.
+// Stdout and stderr of the job are written to the output file. The job is stopped by StopJob, KillJobs or Close
+func (b *Bash) Start(cmd, output string) (int, error) {
+	// job control puts the job into its own process group, so all processes of the job can be stopped together
+	stdout, stderr, exitCode, err := b.Run("set -m\n{\n" + cmd + "\n} >" + quote(output) + " 2>&1 &\nset +m\necho $!")
+	if err != nil {
+		return 0, err
+	}
+	if exitCode != 0 {
+		return 0, errors.Errorf("cannot start job: %v", stderr)
+	}
+	pid, err := strconv.Atoi(stdout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid job pid %v", stdout)
+	}
+	b.groupsMu.Lock()
+	defer b.groupsMu.Unlock()
+	if b.groups == nil {
+		b.groups = map[int]struct{}{}
+	}
+	b.groups[pid] = struct{}{}
+	return pid, nil
+}
+
+// Running returns true if any process of the job started by Start is running
+func (b *Bash) Running(pid int) bool {
+	return exists(processGroup(pid))
+}
+
+// StopJob terminates all processes of the job started by Start. Processes that are still running after the grace period are killed
+func (b *Bash) StopJob(pid int) {
+	b.groupsMu.Lock()
+	delete(b.groups, pid)
+	b.groupsMu.Unlock()
+	b.stop(processGroup(pid))
+}
+
+func (b *Bash) started(pid int) bool {
+	b.groupsMu.Lock()
+	defer b.groupsMu.Unlock()
+	_, ok := b.groups[pid]
+	return ok
+}
+
+func (b *Bash) startedJobs() []int {
+	b.groupsMu.Lock()
+	defer b.groupsMu.Unlock()
+	var result []int
+	for pid := range b.groups {
+		result = append(result, pid)
+	}
+	return result
+}
+
+// stop terminates the process or the process group and kills it if it is still running after the grace period
+func (b *Bash) stop(pid int) {
+	if err := terminate(pid); err != nil {
+		return
+	}
+	for deadline := time.Now().Add(b.gracePeriod); exists(pid) && time.Now().Before(deadline); {
+		time.Sleep(stopPollInterval)
+	}
+	_ = kill(pid)
+}
+
 // Dir returns the directory where the runner instance is located
 func (b *Bash) Dir() string {
 	return b.dir
@@ -104,6 +240,7 @@ func (b *Bash) Init() error {
 		Env:  b.env,
 		Path: p,
 	}
+	setProcessGroup(b.cmd)
 
 	stderr, err := b.cmd.StderrPipe()
 	if err != nil {
@@ -171,7 +308,7 @@ func (b *Bash) Run(cmd string) (stdout, stderr string, exitCode int, err error)
 		return "", "", 0, b.ctx.Err()
 	}
 
-	_, err = b.stdin.Write([]byte(cmd + "\n" + cmdPrintStatusCode + "\n" + cmdPrintStdoutFinish + "\n" + cmdPrintStderrFinish + "\n"))
+	_, err = b.stdin.Write([]byte(cmd + "\n" + b.printStatusCode() + "\n" + cmdPrintStdoutFinish + "\n" + cmdPrintStderrFinish + "\n"))
 	if err != nil {
 		return "", "", 0, err
 	}
@@ -197,6 +334,11 @@ func (b *Bash) Run(cmd string) (stdout, stderr string, exitCode int, err error)
 		exitCodeString = stdout[(lastLineBreak + 1):]
 		stdout = strings.TrimSpace(stdout[:lastLineBreak])
 	}
+	if len(b.varNames) > 0 {
+		if stdout, err = b.extractVars(stdout); err != nil {
+			return "", "", 0, err
+		}
+	}
 	var exitCode64 int64
 	exitCode64, err = strconv.ParseInt(exitCodeString, 0, 9)
 	if err != nil {
@@ -206,3 +348,46 @@ func (b *Bash) Run(cmd string) (stdout, stderr string, exitCode int, err error)
 
 	return stdout, stderr, exitCode, nil
 }
+
+// printStatusCode returns a command that prints the exit code of the last command.
+// Values of the variables are expanded by the same command, so reading them doesn't run commands in the session
+func (b *Bash) printStatusCode() string {
+	if len(b.varNames) == 0 {
+		return cmdPrintStatusCode
+	}
+	var sb strings.Builder
+	sb.WriteString(`printf '\n%s`)
+	sb.WriteString(strings.Repeat(`%s\0`, len(b.varNames)))
+	sb.WriteString(`\n%s\n' ` + varsMessage)
+	for _, name := range b.varNames {
+		sb.WriteString(` "${` + name + `-}"`)
+	}
+	sb.WriteString(` "$?"`)
+	return sb.String()
+}
+
+// extractVars removes values of the variables from the end of stdout and saves them
+func (b *Bash) extractVars(stdout string) (string, error) {
+	index := strings.LastIndex(stdout, varsMessage)
+	if index == -1 {
+		return "", errors.New("cannot read variables of the command")
+	}
+	values := strings.Split(stdout[index+len(varsMessage):], "\x00")
+	if len(values) != len(b.varNames)+1 {
+		return "", errors.New("cannot read variables of the command")
+	}
+	b.vars = map[string]string{}
+	for i, name := range b.varNames {
+		b.vars[name] = values[i]
+	}
+	return strings.TrimSpace(stdout[:index]), nil
+}
+
+// Vars returns values of the variables passed via WithVars after the last command. Unset variables have empty values
+func (b *Bash) Vars() map[string]string {
+	return b.vars
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
@@ -155,6 +155,27 @@ func TestBashExitCode(t *testing.T) {
 	require.Empty(t, stderr)
 }
 
+func TestBashEnv(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+	t.Setenv("GOTESTMD_PROCESS", "process")
+
+	for _, option := range []struct {
+		option   bash.Option
+		expected string
+	}{
+		{option: bash.WithEnv([]string{"GOTESTMD_PASSED=passed"}), expected: "passed unset"},
+		{option: bash.WithExtraEnv([]string{"GOTESTMD_PASSED=passed"}), expected: "passed process"},
+		{option: bash.WithEnv(nil), expected: "unset process"},
+	} {
+		runner, err := bash.New(option.option)
+		require.NoError(t, err)
+		stdout, _, _, err := runner.Run(`echo ${GOTESTMD_PASSED-unset} ${GOTESTMD_PROCESS-unset}`)
+		runner.Close()
+		require.NoError(t, err)
+		require.Equal(t, option.expected, stdout)
+	}
+}
+
 func randomString(n int) string {
 	var letter = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
@@ -165,3 +186,25 @@ func randomString(n int) string {
 	}
 	return string(b)
 }
+
+func TestBashVars(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+	t.Setenv("GOTESTMD_PROCESS", "process")
+
+	runner, err := bash.New(bash.WithVars("GOTESTMD_PROCESS", "GOTESTMD_SESSION"))
+	require.NoError(t, err)
+	defer runner.Close()
+
+	stdout, stderr, exitCode, err := runner.Run("echo output; echo error >&2; GOTESTMD_SESSION=$'multi\\nline'; false")
+	require.NoError(t, err)
+	require.Equal(t, "output", stdout)
+	require.Equal(t, "error", stderr)
+	require.Equal(t, 1, exitCode)
+	require.Equal(t, map[string]string{"GOTESTMD_PROCESS": "process", "GOTESTMD_SESSION": "multi\nline"}, runner.Vars())
+
+	stdout, _, exitCode, err = runner.Run("unset GOTESTMD_SESSION")
+	require.NoError(t, err)
+	require.Empty(t, stdout)
+	require.Zero(t, exitCode)
+	require.Equal(t, map[string]string{"GOTESTMD_PROCESS": "process", "GOTESTMD_SESSION": ""}, runner.Vars())
+}
@@ -16,6 +16,11 @@
 
 package bash
 
+import (
+	"os"
+	"time"
+)
+
 // Option is an option for the Runner
 type Option func(bash *Bash)
 
@@ -26,9 +31,32 @@ func WithDir(dir string) Option {
 	}
 }
 
-// WithEnv sets env variables for the bash runner
+// WithEnv sets env variables for the bash runner. Empty env means the env variables of the process
 func WithEnv(env []string) Option {
 	return func(bash *Bash) {
 		bash.env = env
 	}
 }
+
+// WithExtraEnv sets env variables for the bash runner in addition to the env variables of the process.
+// The passed env variables override the env variables of the process
+func WithExtraEnv(env []string) Option {
+	return func(bash *Bash) {
+		bash.env = append(os.Environ(), env...)
+	}
+}
+
+// WithVars sets names of the variables of the bash session whose values are read after each command, see Bash.Vars.
+// The names must be valid variable names
+func WithVars(names ...string) Option {
+	return func(bash *Bash) {
+		bash.varNames = names
+	}
+}
+
+// WithGracePeriod sets a time that bash and its background jobs have to exit when the runner is closed or jobs are killed
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(bash *Bash) {
+		bash.gracePeriod = gracePeriod
+	}
+}
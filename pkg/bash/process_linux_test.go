@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package bash_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/networkservicemesh/gotestmd/pkg/bash"
+)
+
+// requireStopped checks that processes with the pids are stopped. Zombies are stopped, but may be reaped later
+func requireStopped(t *testing.T, pids ...int) {
+	for _, pid := range pids {
+		require.Eventually(t, func() bool {
+			content, err := os.ReadFile(filepath.Clean(filepath.Join("/proc", strconv.Itoa(pid), "stat")))
+			return err != nil || strings.Contains(string(content), ") Z ")
+		}, time.Second, 10*time.Millisecond, "process %v is running", pid)
+	}
+}
+
+func TestBashCloseStopsJobs(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	runner, err := bash.New(bash.WithGracePeriod(200 * time.Millisecond))
+	require.NoError(t, err)
+
+	_, _, exitCode, err := runner.Run("sleep 100 & trap '' TERM; sleep 100 &")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	pids, err := runner.Jobs()
+	require.NoError(t, err)
+	require.Len(t, pids, 2)
+
+	runner.Close()
+	requireStopped(t, pids...)
+}
+
+func TestBashCloseStopsHangingCommand(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	runner, err := bash.New(bash.WithGracePeriod(100 * time.Millisecond))
+	require.NoError(t, err)
+
+	stdout, _, exitCode, err := runner.Run("echo $$")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	pid, err := strconv.Atoi(stdout)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		_, _, _, _ = runner.Run("sleep 100")
+	}()
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		runner.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "bash is not closed")
+	}
+	requireStopped(t, pid)
+}
+
+func TestBashKillJobs(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	runner, err := bash.New(bash.WithGracePeriod(100 * time.Millisecond))
+	require.NoError(t, err)
+	defer runner.Close()
+
+	_, _, exitCode, err := runner.Run("sleep 100 & (trap '' TERM; sleep 100) &")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	pids, err := runner.Jobs()
+	require.NoError(t, err)
+	require.Len(t, pids, 2)
+
+	require.NoError(t, runner.KillJobs())
+	requireStopped(t, pids...)
+
+	pids, err = runner.Jobs()
+	require.NoError(t, err)
+	require.Empty(t, pids)
+
+	stdout, _, exitCode, err := runner.Run("echo alive")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+	require.Equal(t, "alive", stdout)
+}
+
+func TestBashStartJob(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	runner, err := bash.New(bash.WithGracePeriod(100 * time.Millisecond))
+	require.NoError(t, err)
+	defer runner.Close()
+
+	output := filepath.Join(t.TempDir(), "job.log")
+	_, _, exitCode, err := runner.Run("MESSAGE=started")
+	require.NoError(t, err)
+	require.Zero(t, exitCode)
+
+	pid, err := runner.Start("echo $MESSAGE; sleep 100 & sleep 100", output)
+	require.NoError(t, err)
+	require.True(t, runner.Running(pid))
+	require.Eventually(t, func() bool {
+		content, _ := os.ReadFile(filepath.Clean(output))
+		return string(content) == "started\n"
+	}, time.Second, 10*time.Millisecond)
+
+	runner.StopJob(pid)
+	require.False(t, runner.Running(pid))
+	requireStopped(t, pid)
+}
+
+func TestBashCloseStopsStartedJobs(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	runner, err := bash.New(bash.WithGracePeriod(100 * time.Millisecond))
+	require.NoError(t, err)
+
+	pid, err := runner.Start("sleep 100", filepath.Join(t.TempDir(), "job.log"))
+	require.NoError(t, err)
+
+	runner.Close()
+	requireStopped(t, pid)
+}
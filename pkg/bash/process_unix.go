@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package bash
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group, so background jobs can be terminated together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processGroup returns an id that signals all processes of the group led by the process
+func processGroup(pid int) int {
+	return -pid
+}
+
+func terminate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+func kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+// exists returns true if the process or any process of the group is running.
+// Zombies are ignored, they are already stopped and wait until their parent reaps them
+func exists(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	states, err := processStates(pid)
+	if err != nil {
+		// there is no procfs, so zombies can't be distinguished
+		return true
+	}
+	for _, state := range states {
+		if state != "Z" {
+			return true
+		}
+	}
+	return false
+}
+
+// processStates returns states of the process or processes of the group from procfs
+func processStates(pid int) ([]string, error) {
+	if pid > 0 {
+		state, _, err := processStat(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+		return []string{state}, err
+	}
+	paths, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil || len(paths) == 0 {
+		return nil, os.ErrNotExist
+	}
+	var result []string
+	for _, path := range paths {
+		if state, group, err := processStat(path); err == nil && group == -pid {
+			result = append(result, state)
+		}
+	}
+	return result, nil
+}
+
+// processStat returns the state and the process group from /proc/PID/stat
+func processStat(path string) (state string, group int, err error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", 0, err
+	}
+	// the command name can contain spaces and parentheses, fields are parsed after it
+	fields := strings.Fields(string(content[strings.LastIndexByte(string(content), ')')+1:]))
+	if len(fields) < 3 {
+		return "", 0, os.ErrInvalid
+	}
+	group, err = strconv.Atoi(fields[2])
+	return fields[0], group, err
+}
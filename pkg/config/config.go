@@ -0,0 +1,264 @@
+// Copyright (c) 2020-2023 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config contains gotestmd configuration
+package config
+
+import (
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/networkservicemesh/gotestmd/pkg/tags"
+)
+
+const (
+	// FileName is a name of the config file that is looked up in the input dir
+	FileName = "gotestmd.yaml"
+	// DefaultBasePkg is a package that provides the default bash runner for generated suites. Custom base packages embed its Suite
+	DefaultBasePkg = "github.com/networkservicemesh/gotestmd/pkg/suites/shell"
+)
+
+const (
+	// TargetGo generates golang testify suites
+	TargetGo = "go"
+	// TargetBash generates bash scripts
+	TargetBash = "bash"
+	// TargetMake generates a Makefile with targets for each suite and test
+	TargetMake = "make"
+)
+
+// Retry contains retry policy for the generated commands
+type Retry struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Sections contains headings of the markdown sections.
+// Each section can have several headings, headings are compared case-insensitively
+type Sections struct {
+	Run         []string `yaml:"run"`
+	Cleanup     []string `yaml:"cleanup"`
+	Diagnostics []string `yaml:"diagnostics"`
+	Includes    []string `yaml:"includes"`
+	Requires    []string `yaml:"requires"`
+}
+
+// Templates contains paths to text/template files that override built-in templates
+type Templates struct {
+	Suite     string `yaml:"suite"`
+	Test      string `yaml:"test"`
+	BashSuite string `yaml:"bash-suite"`
+	BashTest  string `yaml:"bash-test"`
+}
+
+// Config contains input dir with .md examples and output dir for generated suites
+type Config struct {
+	InputDir    string        `yaml:"input-dir"`
+	OutputDir   string        `yaml:"output-dir"`
+	BasePkg     string        `yaml:"base-pkg"`
+	Patterns    []string      `yaml:"patterns"`
+	Ignore      []string      `yaml:"ignore"`
+	Timeout     time.Duration `yaml:"timeout"`
+	EnvFile     string        `yaml:"env-file"`
+	Retry       Retry         `yaml:"retry"`
+	Sections    Sections      `yaml:"sections"`
+	Templates   Templates     `yaml:"templates"`
+	BuildTags   string        `yaml:"build-tags"`
+	Targets     []string      `yaml:"targets"`
+	Match       string        `yaml:"match"`
+	Tags        string        `yaml:"tags"`
+	ExcludeTags string        `yaml:"exclude-tags"`
+	EntryPoint  bool          `yaml:"entry-point"`
+	Roots       []string      `yaml:"roots"`
+}
+
+// Default returns Config with default values
+func Default() Config {
+	return Config{
+		BasePkg:  DefaultBasePkg,
+		Patterns: []string{"README.md"},
+		Ignore:   []string{".git"},
+		Sections: Sections{
+			Run:         []string{"Run"},
+			Cleanup:     []string{"Cleanup"},
+			Diagnostics: []string{"Diagnostics", "OnFailure"},
+			Includes:    []string{"Includes"},
+			Requires:    []string{"Requires"},
+		},
+		Targets: []string{TargetGo},
+	}
+}
+
+// Find returns path to the config file located in dir. Returns empty string if there is no config file
+func Find(dir string) string {
+	p := filepath.Join(dir, FileName)
+	if info, err := os.Stat(p); err != nil || info.IsDir() {
+		return ""
+	}
+	return p
+}
+
+// Load reads Config from the yaml file. Values missing in the file are taken from Default.
+// Relative input-dir, output-dir, env-file and template paths are resolved against the dir of the file
+func Load(path string) (Config, error) {
+	result := Default()
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return result, errors.Wrapf(err, "cannot open config %v", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&result); err != nil {
+		return result, errors.Wrapf(err, "cannot parse config %v", path)
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&result.InputDir, &result.OutputDir, &result.EnvFile, &result.Templates.Suite, &result.Templates.Test, &result.Templates.BashSuite, &result.Templates.BashTest} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+
+	return result, nil
+}
+
+// FromArgs returns Config from the os.Args
+func FromArgs(args []string) (Config, error) {
+	result := Default()
+	if err := result.ApplyArgs(args); err != nil {
+		return result, err
+	}
+	return result, result.Validate()
+}
+
+// ApplyArgs overrides input dir, output dir and base pkg by the passed args
+func (c *Config) ApplyArgs(args []string) error {
+	if len(args) > 3 {
+		return errors.New("ARGs have wrong length. Expected: (string)input-dir (string)output-dir (string)base-pkg[optional]")
+	}
+	if len(args) > 0 {
+		c.InputDir = args[0]
+	}
+	if len(args) > 1 {
+		c.OutputDir = args[1]
+	}
+	if len(args) > 2 {
+		c.BasePkg = args[2]
+	}
+	return nil
+}
+
+// HasTarget returns true if the target is enabled
+func (c *Config) HasTarget(target string) bool {
+	for _, t := range c.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the config is invalid
+func (c *Config) Validate() error {
+	if c.InputDir == "" || c.OutputDir == "" {
+		return errors.New("input-dir and output-dir are required. Expected ARGs: (string)input-dir (string)output-dir (string)base-pkg[optional]")
+	}
+	if info, err := os.Stat(c.InputDir); err != nil || !info.IsDir() {
+		return errors.Errorf("input-dir %v is not a directory", c.InputDir)
+	}
+	if c.BasePkg == "" {
+		return errors.New("base-pkg can not be empty")
+	}
+	if len(c.Patterns) == 0 {
+		return errors.New("patterns can not be empty")
+	}
+	for _, pattern := range append(append([]string{}, c.Patterns...), c.Ignore...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid pattern %v", pattern)
+		}
+	}
+	if c.Timeout < 0 {
+		return errors.Errorf("timeout can not be negative: %v", c.Timeout)
+	}
+	if c.Retry.Interval < 0 {
+		return errors.Errorf("retry interval can not be negative: %v", c.Retry.Interval)
+	}
+	if c.BuildTags != "" {
+		if _, err := constraint.Parse("//go:build " + c.BuildTags); err != nil {
+			return errors.Wrapf(err, "invalid build-tags %v", c.BuildTags)
+		}
+	}
+	if err := c.Sections.validate(); err != nil {
+		return err
+	}
+	if len(c.Targets) == 0 {
+		return errors.New("targets can not be empty")
+	}
+	for _, target := range c.Targets {
+		if target != TargetGo && target != TargetBash && target != TargetMake {
+			return errors.Errorf("unknown target %v. Expected one of: %v, %v, %v", target, TargetGo, TargetBash, TargetMake)
+		}
+	}
+	if _, err := regexp.Compile(c.Match); err != nil {
+		return errors.Wrapf(err, "invalid match %v", c.Match)
+	}
+	if _, err := tags.NewSelector(c.Tags, c.ExcludeTags); err != nil {
+		return err
+	}
+	if c.HasTarget(TargetBash) && c.Match == "" && c.Tags == "" && c.ExcludeTags == "" {
+		return errors.New("Flag --bash can be used only with flag --match, --tags or --exclude-tags")
+	}
+	if c.EntryPoint && !c.HasTarget(TargetGo) {
+		return errors.New("Flag --entry-point can not be used with flag --bash")
+	}
+	return nil
+}
+
+func (s *Sections) validate() error {
+	for _, section := range []struct {
+		name     string
+		headings []string
+	}{
+		{name: "run", headings: s.Run},
+		{name: "cleanup", headings: s.Cleanup},
+		{name: "diagnostics", headings: s.Diagnostics},
+		{name: "includes", headings: s.Includes},
+		{name: "requires", headings: s.Requires},
+	} {
+		if len(section.headings) == 0 {
+			return errors.Errorf("section %v should have at least one heading", section.name)
+		}
+		for _, heading := range section.headings {
+			if heading == "" {
+				return errors.Errorf("section %v has an empty heading", section.name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, config.FileName)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	c, err := config.Load(write(`input-dir: examples
+output-dir: ../suites
+env-file: .env
+timeout: 1m
+templates:
+  suite: templates/suite.tmpl
+  bash-test: /templates/test.sh.tmpl
+`))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "examples"), c.InputDir)
+	require.Equal(t, filepath.Join(filepath.Dir(dir), "suites"), c.OutputDir)
+	require.Equal(t, filepath.Join(dir, ".env"), c.EnvFile)
+	require.Equal(t, filepath.Join(dir, "templates/suite.tmpl"), c.Templates.Suite)
+	require.Equal(t, "/templates/test.sh.tmpl", c.Templates.BashTest)
+	require.Empty(t, c.Templates.Test)
+	require.Equal(t, time.Minute, c.Timeout)
+	// values missing in the file are taken from the default config
+	require.Equal(t, config.Default().Patterns, c.Patterns)
+	require.Equal(t, config.DefaultBasePkg, c.BasePkg)
+
+	for content, expected := range map[string]string{
+		"target: bash\n":        "cannot parse config",
+		"timeout: forever\n":    "cannot parse config",
+		"input-dir: [a, b]\n":   "cannot parse config",
+		"retry:\n  attempts: 3": "cannot parse config",
+	} {
+		_, err = config.Load(write(content))
+		require.Error(t, err, content)
+		require.Contains(t, err.Error(), expected, content)
+	}
+
+	_, err = config.Load(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot open config")
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	require.Empty(t, config.Find(dir))
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, config.FileName), 0o700))
+	require.Empty(t, config.Find(dir))
+
+	nested := filepath.Join(dir, "nested")
+	require.NoError(t, os.Mkdir(nested, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, config.FileName), nil, 0o600))
+	require.Equal(t, filepath.Join(nested, config.FileName), config.Find(nested))
+}
+
+func TestApplyArgs(t *testing.T) {
+	c := config.Default()
+	require.NoError(t, c.ApplyArgs(nil))
+	require.Empty(t, c.InputDir)
+
+	require.NoError(t, c.ApplyArgs([]string{"in"}))
+	require.Equal(t, "in", c.InputDir)
+	require.Empty(t, c.OutputDir)
+
+	require.NoError(t, c.ApplyArgs([]string{"examples", "suites", "example.com/base"}))
+	require.Equal(t, "examples", c.InputDir)
+	require.Equal(t, "suites", c.OutputDir)
+	require.Equal(t, "example.com/base", c.BasePkg)
+
+	require.Error(t, c.ApplyArgs([]string{"a", "b", "c", "d"}))
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := func() config.Config {
+		c := config.Default()
+		c.InputDir, c.OutputDir = dir, filepath.Join(dir, "out")
+		return c
+	}
+	c := valid()
+	require.NoError(t, c.Validate())
+
+	for name, test := range map[string]struct {
+		modify   func(c *config.Config)
+		expected string
+	}{
+		"no input dir":             {modify: func(c *config.Config) { c.InputDir = "" }, expected: "input-dir and output-dir are required"},
+		"no output dir":            {modify: func(c *config.Config) { c.OutputDir = "" }, expected: "input-dir and output-dir are required"},
+		"missing input dir":        {modify: func(c *config.Config) { c.InputDir = filepath.Join(dir, "missing") }, expected: "is not a directory"},
+		"empty base pkg":           {modify: func(c *config.Config) { c.BasePkg = "" }, expected: "base-pkg can not be empty"},
+		"no patterns":              {modify: func(c *config.Config) { c.Patterns = nil }, expected: "patterns can not be empty"},
+		"bad pattern":              {modify: func(c *config.Config) { c.Patterns = []string{"[README.md"} }, expected: "invalid pattern"},
+		"bad ignore pattern":       {modify: func(c *config.Config) { c.Ignore = []string{"vendor["} }, expected: "invalid pattern"},
+		"negative timeout":         {modify: func(c *config.Config) { c.Timeout = -time.Second }, expected: "timeout can not be negative"},
+		"negative retry interval":  {modify: func(c *config.Config) { c.Retry.Interval = -time.Second }, expected: "retry interval can not be negative"},
+		"bad build tags":           {modify: func(c *config.Config) { c.BuildTags = "linux &&" }, expected: "invalid build-tags"},
+		"empty section":            {modify: func(c *config.Config) { c.Sections.Run = nil }, expected: "section run should have at least one heading"},
+		"empty heading":            {modify: func(c *config.Config) { c.Sections.Cleanup = []string{""} }, expected: "section cleanup has an empty heading"},
+		"no targets":               {modify: func(c *config.Config) { c.Targets = nil }, expected: "targets can not be empty"},
+		"unknown target":           {modify: func(c *config.Config) { c.Targets = []string{"python"} }, expected: "unknown target python"},
+		"bad match":                {modify: func(c *config.Config) { c.Match = "(" }, expected: "invalid match"},
+		"bad tags":                 {modify: func(c *config.Config) { c.Tags = "calico &&" }, expected: "calico &&"},
+		"bash without selection":   {modify: func(c *config.Config) { c.Targets = []string{config.TargetBash} }, expected: "--bash can be used only with"},
+		"entry point without go":   {modify: func(c *config.Config) { c.Targets, c.Match, c.EntryPoint = []string{config.TargetBash}, "a", true }, expected: "--entry-point can not be used"},
+		"valid bash with match":    {modify: func(c *config.Config) { c.Targets, c.Match = []string{config.TargetBash}, "a" }},
+		"valid make and go target": {modify: func(c *config.Config) { c.Targets = []string{config.TargetGo, config.TargetMake} }},
+	} {
+		c := valid()
+		test.modify(&c)
+		err := c.Validate()
+		if test.expected == "" {
+			require.NoError(t, err, name)
+			continue
+		}
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), test.expected, name)
+	}
+}
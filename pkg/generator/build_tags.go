@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"go/build/constraint"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// buildConstraint combines build constraint expressions with &&. Returns empty string if there are no expressions
+func buildConstraint(exprs ...string) string {
+	var unique []string
+	var seen = map[string]struct{}{}
+	for _, expr := range exprs {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		if _, ok := seen[expr]; ok {
+			continue
+		}
+		seen[expr] = struct{}{}
+		unique = append(unique, "("+expr+")")
+	}
+	if len(unique) == 0 {
+		return ""
+	}
+	expr, err := constraint.Parse("//go:build " + strings.Join(unique, " && "))
+	if err != nil {
+		return strings.Join(unique, " && ")
+	}
+	return expr.String()
+}
+
+// applyBuildTags sets build constraints of the suites. Build tags of an example are kept on its own files:
+// suites and tests that are not built are skipped at runtime, so the generated packages always compile together
+func (g *Generator) applyBuildTags(suites []*Suite) {
+	for _, s := range suites {
+		s.BuildTags = buildConstraint(g.conf.BuildTags, s.FrontMatter.BuildTags)
+		s.globalBuildTags = g.conf.BuildTags
+	}
+}
+
+const skippedSuiteTemplate = `// Code generated by gotestmd DO NOT EDIT.
+
+//go:build {{ .BuildTags }}
+
+package {{ .Name }}
+
+import (
+	"{{ .Base.Pkg }}"
+)
+
+// Suite replaces the suite generated from {{ .Source }} if its build constraint is not satisfied
+type Suite struct {
+	{{ .Base.Name }}.Suite
+}
+
+func (s *Suite) SetupSuite() {
+	s.T().Skip({{ .Reason }})
+}
+
+func (s *Suite) Test() {}
+`
+
+const testBuildTagsTemplate = `// Code generated by gotestmd DO NOT EDIT.
+
+//go:build {{ .BuildTags }}
+
+package {{ .Name }}
+
+const {{ .Const }} = {{ .Value }}
+`
+
+// skipReason returns a quoted reason of skipping a suite or a test with the build constraint
+func skipReason(expr string) string {
+	return strconv.Quote("build constraint " + expr + " is not satisfied")
+}
+
+// testBuildTagsConst returns a name of the constant that is true if the build constraint of the test is satisfied
+func testBuildTagsConst(t *Test) string {
+	return "test" + t.Name + "BuildTags"
+}
+
+// RenderBuildTagFiles returns files that are generated next to the suite for build tags of the example and its tests by their locations.
+// A stub of the suite skips it if the suite is not built. Tests with build tags check constants that are set by the files built with the tags
+func (s *Suite) RenderBuildTagFiles() (map[string]string, error) {
+	var result = map[string]string{}
+	var dir = filepath.Dir(s.Location)
+	var execute = func(name, text string, data interface{}) error {
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse template %v", name)
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return errors.Wrapf(err, "cannot generate %v of suite %v", name, s.Name())
+		}
+		result[filepath.Join(dir, name)] = sb.String()
+		return nil
+	}
+
+	if expr := s.FrontMatter.BuildTags; expr != "" {
+		err := execute("suite_no_tags.gen.go", skippedSuiteTemplate, map[string]interface{}{
+			"BuildTags": buildConstraint(s.globalBuildTags, "!("+expr+")"),
+			"Name":      s.Name(),
+			"Base":      s.Deps[0],
+			"Source":    s.Source,
+			"Reason":    skipReason(expr),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range s.Tests {
+		if t.BuildTags == "" {
+			continue
+		}
+		prefix := "test_" + strings.ToLower(t.Name)
+		for name, value := range map[string]bool{prefix + "_tags.gen.go": true, prefix + "_no_tags.gen.go": false} {
+			expr := t.BuildTags
+			if !value {
+				expr = "!(" + expr + ")"
+			}
+			err := execute(name, testBuildTagsTemplate, map[string]interface{}{
+				"BuildTags": buildConstraint(s.globalBuildTags, expr),
+				"Name":      s.Name(),
+				"Const":     testBuildTagsConst(t),
+				"Value":     value,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
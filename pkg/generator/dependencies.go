@@ -56,7 +56,8 @@ func (d Dependencies) FieldsString() string {
 	return result.String()
 }
 
-// SetupString returns a string that contains a declaration of suite dependencies as part of setup function
+// SetupString returns a string that contains a declaration of suite dependencies as part of setup function.
+// The first dependency is the base suite, the rest are required suites that are set up once and shared by the suites requiring them
 func (d Dependencies) SetupString() string {
 	if len(d) == 0 {
 		return ""
@@ -64,18 +65,7 @@ func (d Dependencies) SetupString() string {
 
 	var result strings.Builder
 
-	result.WriteString("parents := []interface{}{")
-	result.WriteString("&s.Suite")
-	for i := 1; i < len(d); i++ {
-		if i < len(d) {
-			result.WriteString(",")
-		}
-		result.WriteString("&s.")
-		result.WriteString(d[i].Name())
-		result.WriteString("Suite")
-	}
-	result.WriteString("}\n")
-
+	result.WriteString("parents := []interface{}{&s.Suite}\n")
 	result.WriteString(`for _, p := range parents {
 		if v, ok := p.(suite.TestingSuite); ok {
 			v.SetT(s.T())
@@ -86,6 +76,19 @@ func (d Dependencies) SetupString() string {
 	}
 `)
 
+	if len(d) > 1 {
+		result.WriteString("s.SetupRequired(")
+		for i := 1; i < len(d); i++ {
+			if i > 1 {
+				result.WriteString(", ")
+			}
+			result.WriteString("&s.")
+			result.WriteString(d[i].Name())
+			result.WriteString("Suite")
+		}
+		result.WriteString(")\n")
+	}
+
 	return result.String()
 }
 
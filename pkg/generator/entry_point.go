@@ -0,0 +1,303 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+)
+
+const entryPointTemplate = `// Code generated by gotestmd DO NOT EDIT.
+{{ if .BuildTags }}
+//go:build {{ .BuildTags }}
+{{ end }}
+package {{ .Name }}
+
+import (
+	{{- if .Branches }}
+	"sync"
+	{{- end }}
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+{{ if .Shell }}
+	"{{ .Shell }}"
+{{- end }}
+{{ range .Suites }}
+	{{ if ne .Alias .Name }}{{ .Alias }} {{ end }}"{{ .Pkg }}"{{ end }}
+)
+
+func TestEntryPoint(t *testing.T) {
+{{- if .Shell }}
+	// required suites are set up once and cleaned up when all suites finish
+	{{ .ShellName }}.ShareSetup(t)
+{{- end }}
+{{- if .Branches }}
+	// parallel suites that set up the same suites are run one by one. The lock is released after the cleanup of the suite
+	var branches [{{ .Branches }}]sync.Mutex
+{{- end }}
+{{- range .Suites }}
+	t.Run("{{ .Title }}", func(t *testing.T) {
+		{{- if .Labels }}
+		s := new({{ .Alias }}.Suite)
+		s.SetT(t)
+		s.SkipUnlessLabels({{ .Labels }})
+		{{- end }}
+		{{- if .Parallel }}
+		t.Parallel()
+		{{- if ge .Branch 0 }}
+		branches[{{ .Branch }}].Lock()
+		t.Cleanup(branches[{{ .Branch }}].Unlock)
+		{{- end }}
+		{{- end }}
+		{{- if .Labels }}
+		suite.Run(t, s)
+		{{- else }}
+		suite.Run(t, new({{ .Alias }}.Suite))
+		{{- end }}
+	})
+{{- end }}
+}
+`
+
+// EntryPoint represents a template for generating a test that runs all top-level suites
+type EntryPoint struct {
+	Location  string
+	Name      string
+	BuildTags string
+	Suites    []*Suite
+}
+
+// String returns a string that contains generated entry point test
+func (e *EntryPoint) String() string {
+	tmpl, err := template.New("entrypoint").Parse(entryPointTemplate)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	type suiteData struct {
+		Alias    string
+		Name     string
+		Pkg      string
+		Title    string
+		Labels   string
+		Parallel bool
+		Branch   int
+	}
+
+	var suites []*suiteData
+	// Imports of the entry point are reserved
+	var aliases = map[string]int{"sync": 1, "testing": 1, "suite": 1}
+	// shell package shares setup of the required suites. Custom base packages embed its suite, but don't provide ShareSetup
+	var shell Dependency
+	if e.requiresSuites() {
+		shell = Dependency(config.DefaultBasePkg)
+		aliases[shell.Name()]++
+	}
+	var branchOf, branches = parallelBranches(e.Suites)
+	for _, s := range e.Suites {
+		alias := s.Name()
+		if aliases[alias] > 0 {
+			alias = normalizeName(s.Path)
+		}
+		for aliases[alias] > 0 {
+			alias += "pkg"
+		}
+		aliases[alias]++
+		var labels string
+		if s.labeled {
+			labels = labelSetsString(s.labelSets()...)
+		}
+		branch, ok := branchOf[s]
+		if !ok {
+			branch = -1
+		}
+		suites = append(suites, &suiteData{
+			Alias:    alias,
+			Name:     s.Name(),
+			Pkg:      s.Pkg(),
+			Title:    s.Path,
+			Labels:   labels,
+			Parallel: s.FrontMatter.Parallel,
+			Branch:   branch,
+		})
+	}
+
+	var result = new(strings.Builder)
+	err = tmpl.Execute(result, struct {
+		Name      string
+		BuildTags string
+		Shell     Dependency
+		ShellName string
+		Branches  int
+		Suites    []*suiteData
+	}{
+		Name:      e.Name,
+		BuildTags: e.BuildTags,
+		Shell:     shell,
+		ShellName: shell.Name(),
+		Branches:  branches,
+		Suites:    suites,
+	})
+	if err != nil {
+		panic(err.Error())
+	}
+	return result.String()
+}
+
+// GenerateEntryPoint generates an entry point test for the suites that are not included by any other suite.
+// If roots are passed, only the suites with matching paths are used.
+func (g *Generator) GenerateEntryPoint(suites []*Suite, roots ...string) *EntryPoint {
+	result := rootSuites(suites, roots...)
+
+	absDir, _ := filepath.Abs(g.conf.OutputDir)
+
+	return &EntryPoint{
+		Location:  filepath.Join(g.conf.OutputDir, "entry_point_test.go"),
+		Name:      normalizeName(filepath.Base(absDir)),
+		BuildTags: buildConstraint(g.conf.BuildTags),
+		Suites:    result,
+	}
+}
+
+// rootSuites returns the suites that are not included by any other suite sorted by package.
+// Suites required by other suites are set up by them, so they are skipped unless roots are passed.
+// If roots are passed, only the suites with matching paths are returned.
+func rootSuites(suites []*Suite, roots ...string) []*Suite {
+	var included = map[*Suite]struct{}{}
+	var required = map[*Suite]struct{}{}
+	for _, s := range suites {
+		for _, child := range s.Children {
+			included[child] = struct{}{}
+		}
+		for _, parent := range s.Parents {
+			required[parent] = struct{}{}
+		}
+	}
+
+	var selected = map[string]struct{}{}
+	for _, root := range roots {
+		selected[strings.ToLower(filepath.ToSlash(filepath.Clean(root)))] = struct{}{}
+	}
+
+	var result []*Suite
+	for _, s := range suites {
+		if _, ok := included[s]; ok {
+			continue
+		}
+		if _, ok := selected[s.Path]; len(selected) > 0 && !ok {
+			continue
+		}
+		if _, ok := required[s]; len(selected) == 0 && ok {
+			continue
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pkg() < result[j].Pkg()
+	})
+	return result
+}
+
+// requiresSuites returns true if any of the suites or their included suites requires other suites
+func (e *EntryPoint) requiresSuites() bool {
+	for _, s := range e.Suites {
+		for _, current := range s.setupSuites() {
+			if len(current.Parents) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parallelBranches groups parallel suites that set up the same suites, so they are not run at the same time.
+// Returns indexes of the groups with more than one suite and the number of such groups.
+// Suites in other groups are independent branches of the Requires graph and can be run concurrently.
+func parallelBranches(suites []*Suite) (map[*Suite]int, int) {
+	var groups = make([]int, len(suites))
+	var find func(i int) int
+	find = func(i int) int {
+		if groups[i] != i {
+			groups[i] = find(groups[i])
+		}
+		return groups[i]
+	}
+
+	var owners = map[*Suite]int{}
+	for i, s := range suites {
+		groups[i] = i
+		if !s.FrontMatter.Parallel {
+			continue
+		}
+		for _, current := range s.setupSuites() {
+			if owner, ok := owners[current]; ok {
+				groups[find(i)] = find(owner)
+			} else {
+				owners[current] = i
+			}
+		}
+	}
+
+	var sizes = map[int]int{}
+	for i, s := range suites {
+		if s.FrontMatter.Parallel {
+			sizes[find(i)]++
+		}
+	}
+
+	var result = map[*Suite]int{}
+	var indexes = map[int]int{}
+	for i, s := range suites {
+		group := find(i)
+		if !s.FrontMatter.Parallel || sizes[group] < 2 {
+			continue
+		}
+		if _, ok := indexes[group]; !ok {
+			indexes[group] = len(indexes)
+		}
+		result[s] = indexes[group]
+	}
+	return result, len(indexes)
+}
+
+// setupSuites returns the suite, its included suites and all suites they require
+func (s *Suite) setupSuites() []*Suite {
+	var result []*Suite
+	var visited = map[*Suite]struct{}{}
+	var visit func(current *Suite)
+	visit = func(current *Suite) {
+		if _, ok := visited[current]; ok {
+			return
+		}
+		visited[current] = struct{}{}
+		result = append(result, current)
+		for _, p := range current.Parents {
+			visit(p)
+		}
+		for _, child := range current.Children {
+			visit(child)
+		}
+	}
+	visit(s)
+	return result
+}
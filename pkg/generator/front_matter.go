@@ -0,0 +1,217 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+// goChecks returns statements that skip or fail the test before running the steps. The env is loaded before checking required env variables
+func goChecks(fm parser.FrontMatter, loadEnv string) string {
+	var sb strings.Builder
+	if fm.Skip != "" {
+		_, _ = fmt.Fprintf(&sb, "s.T().Skip(%q)\n", fm.Skip)
+	}
+	if len(fm.Platforms) > 0 {
+		_, _ = fmt.Fprintf(&sb, "s.SkipUnlessPlatform(%v)\n", quoteAll(fm.Platforms))
+	}
+	sb.WriteString(loadEnv)
+	if len(fm.RequiresEnv) > 0 {
+		_, _ = fmt.Fprintf(&sb, "s.RequireEnv(%v)\n", quoteAll(fm.RequiresEnv))
+	}
+	return sb.String()
+}
+
+// goLoadEnv returns a statement that loads the env file and env variables of the front matters. Later front matters take precedence
+func goLoadEnv(file string, fms ...parser.FrontMatter) string {
+	var env = mergeEnv(fms...)
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "s.LoadEnv(%q", file)
+	for _, name := range sortedKeys(env) {
+		_, _ = fmt.Fprintf(&sb, ", %q", name+"="+env[name])
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+// bashPresetEnv records env variables of the environment before the script sets env variables of the front matters,
+// so env variables of a test override env variables of its suite, but not env variables of the environment
+const bashPresetEnv = "\tdeclare -A preset_env\n\tfor name in $(compgen -e); do preset_env[$name]=1; done\n"
+
+// bashEnvFileTemplate exports env variables of the env file unless they are recorded by bashPresetEnv.
+// Env variables of the front matters are set later, so they take precedence over the env file
+const bashEnvFileTemplate = `	if [ -f %[1]v ]; then
+		while IFS= read -r line || [ -n "$line" ]; do
+			[[ $line =~ ^[[:space:]]*(#|$) ]] && continue
+			if ! [[ $line =~ ^[[:space:]]*(export[[:space:]]+)?([a-zA-Z_][a-zA-Z0-9_]*)[[:space:]]*=[[:space:]]*(.*[^[:space:]])?[[:space:]]*$ ]]; then
+				echo "invalid line of env file "%[1]v": $line" >&2
+				exit 1
+			fi
+			name="${BASH_REMATCH[2]}"
+			value="${BASH_REMATCH[3]}"
+			if [[ $value =~ ^\"(.*)\"$ || $value =~ ^\'(.*)\'$ ]]; then
+				value="${BASH_REMATCH[1]}"
+			fi
+			[ -n "${preset_env[$name]}" ] || export "$name=$value"
+		done <%[1]v
+	fi
+`
+
+// bashLoadEnv returns commands that record env variables of the environment and export env variables of the env file and the front matters.
+// Front matters take precedence over the env file, later front matters take precedence over earlier ones. Returns empty string if there are no env variables
+func bashLoadEnv(file string, fms ...parser.FrontMatter) string {
+	env := mergeEnv(fms...)
+	if file == "" && len(env) == 0 {
+		return ""
+	}
+	return bashPresetEnv + bashEnvFile(file) + bashEnv(env, "export")
+}
+
+// bashEnvFile returns commands that export env variables of the env file. The file path is resolved at generation time
+func bashEnvFile(file string) string {
+	if file == "" {
+		return ""
+	}
+	absFile, _ := filepath.Abs(file)
+	return fmt.Sprintf(bashEnvFileTemplate, bashQuote(absFile))
+}
+
+// bashEnv returns commands that set env variables unless they are recorded by bashPresetEnv.
+// The declare command sets a variable, e.g. "export"
+func bashEnv(env map[string]string, declare string) string {
+	var sb strings.Builder
+	for _, name := range sortedKeys(env) {
+		_, _ = fmt.Fprintf(&sb, "\t[ -n \"${preset_env[%v]}\" ] || %v %v=%v\n", name, declare, name, bashQuote(env[name]))
+	}
+	return sb.String()
+}
+
+// bashChecks returns commands that skip or fail the bash function before running the steps.
+// The skip command stops the function, e.g. "return 0"
+func bashChecks(fm parser.FrontMatter, skip string) string {
+	var sb strings.Builder
+	if fm.Skip != "" {
+		_, _ = fmt.Fprintf(&sb, "\techo %v\n\t%v\n", bashQuote("skip: "+fm.Skip), skip)
+	}
+	if len(fm.Platforms) > 0 {
+		var patterns []string
+		for _, platform := range fm.Platforms {
+			patterns = append(patterns, bashPlatformPatterns(platform)...)
+		}
+		sb.WriteString("\tcase \"$(uname -s | tr '[:upper:]' '[:lower:]')/$(uname -m)\" in\n")
+		_, _ = fmt.Fprintf(&sb, "\t%v) ;;\n", strings.Join(patterns, "|"))
+		_, _ = fmt.Fprintf(&sb, "\t*) echo %v; %v ;;\n", bashQuote("skip: platform is not one of "+strings.Join(fm.Platforms, ", ")), skip)
+		sb.WriteString("\tesac\n")
+	}
+	for _, env := range fm.RequiresEnv {
+		_, _ = fmt.Fprintf(&sb, "\t[ -n \"${%v+x}\" ] || { echo 'skip: required env variable %v is not set'; %v; }\n", env, env, skip)
+	}
+	return sb.String()
+}
+
+// bashRetryPolicy overrides try_run settings for the bash function. Values set in the environment take precedence
+func bashRetryPolicy(fm parser.FrontMatter) string {
+	var vars []string
+	if fm.Timeout > 0 {
+		vars = append(vars, fmt.Sprintf(`RETRY_TIMEOUT_SECONDS="${RETRY_TIMEOUT_SECONDS:-%v}"`, int(math.Ceil(fm.Timeout.Seconds()))))
+	}
+	if fm.Retry != nil && fm.Retry.Interval > 0 {
+		vars = append(vars, fmt.Sprintf(`RETRY_INTERVAL="${RETRY_INTERVAL:-%v}"`, secondsString(fm.Retry.Interval)))
+	}
+	if fm.Retry != nil && fm.Retry.Attempts > 0 {
+		vars = append(vars, fmt.Sprintf(`RETRY_ATTEMPTS="${RETRY_ATTEMPTS:-%v}"`, fm.Retry.Attempts))
+	}
+	if len(vars) == 0 {
+		return ""
+	}
+	return "\tlocal " + strings.Join(vars, " ") + "\n"
+}
+
+// metadata returns owners and labels of the example as comment lines
+func metadata(prefix string, fm parser.FrontMatter) string {
+	var sb strings.Builder
+	if len(fm.Owners) > 0 {
+		_, _ = fmt.Fprintf(&sb, "%v Owners: %v\n", prefix, strings.Join(fm.Owners, ", "))
+	}
+	if len(fm.Labels) > 0 {
+		_, _ = fmt.Fprintf(&sb, "%v Labels: %v\n", prefix, strings.Join(fm.Labels, ", "))
+	}
+	return sb.String()
+}
+
+func timeoutOrDefault(fm parser.FrontMatter, timeout time.Duration) time.Duration {
+	if fm.Timeout > 0 {
+		return fm.Timeout
+	}
+	return timeout
+}
+
+func bashPlatformPatterns(platform string) []string {
+	goos, goarch, ok := strings.Cut(platform, "/")
+	if !ok {
+		return []string{goos + "/*"}
+	}
+	var result = []string{platform}
+	switch goarch {
+	case "amd64":
+		result = append(result, goos+"/x86_64")
+	case "arm64":
+		result = append(result, goos+"/aarch64")
+	case "386":
+		result = append(result, goos+"/i686", goos+"/i386")
+	}
+	return result
+}
+
+// mergeEnv returns env variables of the front matters. Later front matters take precedence
+func mergeEnv(fms ...parser.FrontMatter) map[string]string {
+	var result = map[string]string{}
+	for _, fm := range fms {
+		for name, value := range fm.Env {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func quoteAll(values []string) string {
+	var quoted []string
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func bashQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
@@ -28,38 +28,57 @@ import (
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
-	"github.com/networkservicemesh/gotestmd/internal/config"
-	"github.com/networkservicemesh/gotestmd/internal/linker"
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+	"github.com/networkservicemesh/gotestmd/pkg/linker"
 )
 
 // Generator can generate suites from the slice of linker.LinedExample
 type Generator struct {
-	conf config.Config
+	conf      config.Config
+	target    string
+	templates *Templates
 }
 
 // New creates new Generator instance
-func New(conf config.Config) *Generator {
-	return &Generator{
-		conf: conf,
+func New(conf config.Config, options ...Option) *Generator {
+	g := &Generator{
+		conf:      conf,
+		target:    config.TargetGo,
+		templates: DefaultTemplates(),
 	}
+	for _, o := range options {
+		o(g)
+	}
+	return g
 }
 
 // Generate generates suites based on passed examples
-func (g *Generator) Generate(examples ...*linker.LinkedExample) []*Suite {
+func (g *Generator) Generate(examples ...*linker.LinkedExample) ([]*Suite, error) {
 	var result []*Suite
 	var tests = map[string][]*Test{}
 	var index = map[string]*Suite{}
 	var children = map[string][]*Suite{}
-	moduleName := moduleName(g.conf.OutputDir)
+	moduleName, err := moduleName(g.conf.OutputDir)
+	if err != nil {
+		return nil, err
+	}
 	for _, e := range examples {
 		if e.IsLeaf() {
 			_, name := path.Split(e.Name)
+			if e.FrontMatter.Name != "" {
+				name = e.FrontMatter.Name
+			}
 			for _, parent := range e.Parents {
 				tests[parent.Name] = append(tests[parent.Name], &Test{
-					Dir:     e.Dir,
-					Name:    cases.Title(language.Und, cases.NoLower).String(nameRegex.ReplaceAllString(name, "_")),
-					Cleanup: e.Cleanup,
-					Run:     e.Run,
+					Dir:         e.Dir,
+					Name:        cases.Title(language.Und, cases.NoLower).String(nameRegex.ReplaceAllString(name, "_")),
+					Cleanup:     e.Cleanup,
+					Run:         e.Run,
+					Diagnostics: e.Diagnostics,
+					Timeout:     timeoutOrDefault(e.FrontMatter, g.conf.Timeout),
+					BuildTags:   e.FrontMatter.BuildTags,
+					FrontMatter: e.FrontMatter,
+					templates:   g.templates,
 				})
 			}
 			continue
@@ -74,19 +93,28 @@ func (g *Generator) Generate(examples ...*linker.LinkedExample) []*Suite {
 		depsToSetup = append(depsToSetup, normalizeDeps(moduleName, e.ParentDependencies())...)
 
 		location := filepath.Join(g.conf.OutputDir, strings.ToLower(e.Name))
-		if g.conf.Bash {
+		if g.target == config.TargetBash {
 			location = filepath.Join(location, "suite.gen.sh")
 		} else {
 			location = filepath.Join(location, "suite.gen.go")
 		}
 		s := &Suite{
-			Dir:         e.Dir,
-			Location:    location,
-			Dependency:  Dependency(path.Join(g.conf.OutputDir, strings.ToLower(e.Name))),
-			Cleanup:     e.Cleanup,
-			Run:         e.Run,
-			Deps:        deps,
-			DepsToSetup: depsToSetup,
+			Dir:           e.Dir,
+			Location:      location,
+			Source:        filepath.ToSlash(e.File),
+			Path:          filepath.ToSlash(strings.ToLower(e.Name)),
+			Dependency:    normalizeDeps(moduleName, []string{e.Name})[0],
+			Cleanup:       e.Cleanup,
+			Run:           e.Run,
+			Diagnostics:   e.Diagnostics,
+			Deps:          deps,
+			DepsToSetup:   depsToSetup,
+			Timeout:       timeoutOrDefault(e.FrontMatter, g.conf.Timeout),
+			RetryInterval: g.conf.Retry.Interval,
+			EnvFile:       g.conf.EnvFile,
+			BuildTags:     e.FrontMatter.BuildTags,
+			FrontMatter:   e.FrontMatter,
+			templates:     g.templates,
 		}
 
 		// Remember if suite is a subsuite
@@ -114,5 +142,8 @@ func (g *Generator) Generate(examples ...*linker.LinkedExample) []*Suite {
 		}
 	}
 
-	return result
+	g.applyBuildTags(result)
+	g.applyLabels(result)
+
+	return result, nil
 }
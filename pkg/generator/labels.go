@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector decides if the suite should be generated. The test is nil when the suite itself is checked
+type Selector func(s *Suite, t *Test) bool
+
+// Select keeps the suites accepted by the selector with all their tests and included suites.
+// Tests of other suites are kept if the selector accepts them, such suites are kept if they include kept tests or suites.
+// All suites required by the kept suites are kept too.
+func Select(suites []*Suite, selector Selector) []*Suite {
+	var keep = map[*Suite]bool{}
+	var tests = map[*Suite][]*Test{}
+	var keepAll func(*Suite)
+	keepAll = func(s *Suite) {
+		keep[s] = true
+		tests[s] = s.Tests
+		for _, child := range s.Children {
+			keepAll(child)
+		}
+	}
+	var visit func(*Suite) bool
+	visit = func(s *Suite) bool {
+		if kept, ok := keep[s]; ok {
+			return kept
+		}
+		if selector(s, nil) {
+			keepAll(s)
+			return true
+		}
+		keep[s] = false
+		var kept bool
+		for _, t := range s.Tests {
+			if selector(s, t) {
+				tests[s] = append(tests[s], t)
+				kept = true
+			}
+		}
+		for _, child := range s.Children {
+			if visit(child) {
+				kept = true
+			}
+		}
+		keep[s] = kept
+		return kept
+	}
+	for _, s := range suites {
+		visit(s)
+	}
+
+	var require func(*Suite)
+	require = func(s *Suite) {
+		for _, parent := range s.Parents {
+			if !keep[parent] {
+				keep[parent] = true
+				require(parent)
+			}
+		}
+	}
+	for _, s := range suites {
+		if keep[s] {
+			require(s)
+		}
+	}
+
+	var removed = map[Dependency]struct{}{}
+	for _, s := range suites {
+		if !keep[s] {
+			removed[s.Dependency] = struct{}{}
+		}
+	}
+
+	var result []*Suite
+	for _, s := range suites {
+		if !keep[s] {
+			continue
+		}
+		s.Tests = tests[s]
+		var children []*Suite
+		for _, child := range s.Children {
+			if keep[child] {
+				children = append(children, child)
+			}
+		}
+		s.Children = children
+		var deps Dependencies
+		for _, dep := range s.Deps {
+			if _, ok := removed[dep]; !ok {
+				deps = append(deps, dep)
+			}
+		}
+		s.Deps = deps
+		result = append(result, s)
+	}
+	return result
+}
+
+// applyLabels sets labels of the suites and tests. Suites and tests inherit labels of the suites including them
+func (g *Generator) applyLabels(suites []*Suite) {
+	var labeled bool
+	var included = map[*Suite]struct{}{}
+	for _, s := range suites {
+		labeled = labeled || len(s.FrontMatter.Labels) > 0
+		for _, t := range s.Tests {
+			labeled = labeled || len(t.FrontMatter.Labels) > 0
+		}
+		for _, child := range s.Children {
+			included[child] = struct{}{}
+		}
+	}
+
+	var visit func(s *Suite, inherited []string)
+	visit = func(s *Suite, inherited []string) {
+		s.Labels = mergeLabels(s.Labels, inherited, s.FrontMatter.Labels)
+		s.labeled = labeled
+		for _, t := range s.Tests {
+			t.Labels = mergeLabels(t.Labels, s.Labels, t.FrontMatter.Labels)
+			t.labeled = labeled
+		}
+		for _, child := range s.Children {
+			visit(child, s.Labels)
+		}
+	}
+	for _, s := range suites {
+		if _, ok := included[s]; !ok {
+			visit(s, nil)
+		}
+	}
+}
+
+// labelSets returns labels of the suite, its tests and all included suites
+func (s *Suite) labelSets() [][]string {
+	var result = [][]string{s.Labels}
+	for _, t := range s.Tests {
+		if len(t.Run)+len(t.Cleanup)+len(t.Diagnostics) > 0 {
+			result = append(result, t.Labels)
+		}
+	}
+	for _, child := range s.Children {
+		result = append(result, child.labelSets()...)
+	}
+	return result
+}
+
+func labelSetsString(sets ...[]string) string {
+	var result []string
+	var seen = map[string]struct{}{}
+	for _, set := range sets {
+		key := strings.Join(set, ",")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if len(set) == 0 {
+			result = append(result, "nil")
+			continue
+		}
+		result = append(result, fmt.Sprintf("[]string{%v}", quoteAll(set)))
+	}
+	return strings.Join(result, ", ")
+}
+
+func mergeLabels(sets ...[]string) []string {
+	var result []string
+	var seen = map[string]struct{}{}
+	for _, set := range sets {
+		for _, label := range set {
+			if _, ok := seen[label]; !ok {
+				seen[label] = struct{}{}
+				result = append(result, label)
+			}
+		}
+	}
+	return result
+}
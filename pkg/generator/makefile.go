@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const makefileTemplate = `# Code generated by gotestmd DO NOT EDIT.
+
+SHELL := /bin/bash
+.ONESHELL:
+.DEFAULT_GOAL := help
+
+.PHONY: help setup test cleanup{{ range .Targets }} {{ .Name }}{{ end }}
+
+help:
+	@echo 'Targets:'
+	echo '  setup                 set up all suites'
+	echo '  test                  run tests of all suites'
+	echo '  cleanup               clean up all suites'
+{{- range .Targets }}
+	echo '  {{ .Name }}'
+{{- end }}
+
+setup:{{ range .Suites }} setup-{{ .Path }}{{ end }}
+
+test:{{ range .Suites }} test-{{ .Path }}{{ end }}
+
+cleanup:{{ range .Suites }} cleanup-{{ .Path }}{{ end }}
+{{ range .Targets }}
+{{ .Name }}:{{ range .Prerequisites }} {{ . }}{{ end }}
+{{ .Recipe }}{{ end }}`
+
+// Makefile represents a template for generating a Makefile with targets for each suite and test
+type Makefile struct {
+	Location string
+	Suites   []*Suite
+}
+
+type makeTarget struct {
+	Name          string
+	Prerequisites []string
+	Recipe        string
+}
+
+// String returns a string that contains generated Makefile
+func (m *Makefile) String() string {
+	result, _ := m.Render()
+	return result
+}
+
+// Render returns a string that contains generated Makefile or an error if the template can't be executed
+func (m *Makefile) Render() (string, error) {
+	tmpl, err := template.New("makefile").Parse(makefileTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot parse makefile template")
+	}
+
+	var includers = map[*Suite][]*Suite{}
+	var dependents = map[*Suite][]*Suite{}
+	for _, s := range m.Suites {
+		for _, child := range s.Children {
+			includers[child] = append(includers[child], s)
+		}
+		for _, parent := range s.Parents {
+			dependents[parent] = append(dependents[parent], s)
+		}
+	}
+
+	var targets []*makeTarget
+	for _, s := range m.Suites {
+		absDir, _ := filepath.Abs(s.Dir)
+		run := s.Run.withBackground(absDir)
+
+		// A suite is set up after the suites it requires and the suites including it
+		setup := &makeTarget{Name: "setup-" + s.Path}
+		for _, p := range append(append([]*Suite{}, s.Parents...), includers[s]...) {
+			setup.Prerequisites = append(setup.Prerequisites, "setup-"+p.Path)
+		}
+		setup.Recipe = makeRecipe(
+			"\techo "+bashQuote("setup suite "+s.Path)+"\n",
+			bashLoadEnv(s.EnvFile, s.FrontMatter),
+			bashChecks(s.FrontMatter, "exit 0"),
+			append(NewBody("cd "+absDir), run...).BashString(true, false),
+		)
+
+		// A suite is cleaned up after the suites requiring it and the suites it includes
+		cleanup := &makeTarget{Name: "cleanup-" + s.Path}
+		for _, d := range append(append([]*Suite{}, dependents[s]...), s.Children...) {
+			cleanup.Prerequisites = append(cleanup.Prerequisites, "cleanup-"+d.Path)
+		}
+		cleanup.Recipe = makeRecipe(
+			"\techo "+bashQuote("cleanup suite "+s.Path)+"\n",
+			bashLoadEnv(s.EnvFile, s.FrontMatter),
+			append(append(NewBody("cd "+absDir), run.Undo()...), s.Cleanup...).BashString(false, false),
+			"\t# cleanup shouldn't report errors\n\ttrue\n",
+		)
+
+		test := &makeTarget{Name: "test-" + s.Path, Prerequisites: []string{setup.Name}, Recipe: "\t@true\n"}
+		var tests []*makeTarget
+		for _, t := range s.Tests {
+			if len(t.Run)+len(t.Cleanup) == 0 {
+				continue
+			}
+			testDir, _ := filepath.Abs(t.Dir)
+			testRun := t.Run.withBackground(testDir)
+			tests = append(tests, &makeTarget{
+				Name:          "test-" + s.Path + "/Test" + t.Name,
+				Prerequisites: []string{setup.Name},
+				Recipe: makeRecipe(
+					bashLoadEnv(s.EnvFile, s.FrontMatter, t.FrontMatter),
+					bashChecks(s.FrontMatter, "exit 0"),
+					bashChecks(t.FrontMatter, "exit 0"),
+					append(NewBody("cd "+testDir), testRun...).BashString(true, false),
+					append(testRun.Undo(), t.Cleanup...).BashString(false, false),
+				),
+			})
+			test.Prerequisites = append(test.Prerequisites, tests[len(tests)-1].Name)
+		}
+		for _, child := range s.Children {
+			test.Prerequisites = append(test.Prerequisites, "test-"+child.Path)
+		}
+
+		targets = append(targets, setup, test)
+		targets = append(targets, tests...)
+		targets = append(targets, cleanup)
+	}
+
+	for _, t := range targets {
+		sort.Strings(t.Prerequisites)
+	}
+
+	var result = new(strings.Builder)
+	err = tmpl.Execute(result, struct {
+		Suites  []*Suite
+		Targets []*makeTarget
+	}{
+		Suites:  m.Suites,
+		Targets: targets,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate makefile")
+	}
+	return result.String(), nil
+}
+
+// makeRecipe joins bash commands into a silent recipe of the .ONESHELL Makefile
+func makeRecipe(parts ...string) string {
+	var sb strings.Builder
+	for i, line := range strings.Split(strings.TrimRight(strings.Join(parts, ""), "\n"), "\n") {
+		sb.WriteString("\t")
+		if i == 0 {
+			sb.WriteString("@")
+		}
+		sb.WriteString(strings.ReplaceAll(strings.TrimPrefix(line, "\t"), "$", "$$"))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// GenerateMakefile generates a Makefile with setup, test and cleanup targets for each suite
+func (g *Generator) GenerateMakefile(suites []*Suite) *Makefile {
+	var sorted = append([]*Suite{}, suites...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	return &Makefile{
+		Location: filepath.Join(g.conf.OutputDir, "Makefile"),
+		Suites:   sorted,
+	}
+}
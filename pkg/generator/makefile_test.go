@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/generator"
+)
+
+func TestMakefile(t *testing.T) {
+	producer := newSuite("producer")
+	producer.Run = generator.NewBody("echo $HOME\necho done")
+	consumer := newSuite("producer/consumer", producer)
+	consumer.Tests = []*generator.Test{{
+		Dir:  "producer/consumer/check",
+		Name: "Check",
+		Run:  generator.NewBody("echo check"),
+	}}
+	consumer.Tests[0].FrontMatter.Env = map[string]string{"NAMESPACE": "check"}
+
+	content, err := (&generator.Makefile{Suites: []*generator.Suite{producer, consumer}}).Render()
+	require.NoError(t, err)
+
+	require.Contains(t, content, ".ONESHELL:\n")
+	require.Contains(t, content, "\nsetup-producer:\n\t@echo 'setup suite producer'\n")
+	require.Contains(t, content, "\techo $$HOME\n\techo done\n\t[ $$? = 0 ] || exit 1\n")
+	require.Contains(t, content, "\nsetup-producer/consumer: setup-producer\n")
+	require.Contains(t, content, "\ntest-producer/consumer: setup-producer/consumer test-producer/consumer/TestCheck\n")
+	require.Contains(t, content, "\ntest-producer/consumer/TestCheck: setup-producer/consumer\n\t@declare -A preset_env\n\tfor name in $$(compgen -e); do preset_env[$$name]=1; done\n\t[ -n \"$${preset_env[NAMESPACE]}\" ] || export NAMESPACE='check'\n")
+	require.Contains(t, content, "\ncleanup-producer: cleanup-producer/consumer\n")
+	require.Contains(t, content, "\ncleanup-producer/consumer:\n")
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"sort"
+	"time"
+)
+
+// MatrixEntry describes a top-level suite for a CI strategy.matrix
+type MatrixEntry struct {
+	// Suite is a path of the suite in the output dir, e.g. producer/consumer2
+	Suite string `json:"suite"`
+	// Package is an import path of the generated suite
+	Package string `json:"package"`
+	// Tags contains labels of the suite, its tests and included suites
+	Tags []string `json:"tags"`
+	// EstimatedDuration is a sum of estimated durations of the suite, its tests, included and required suites
+	EstimatedDuration string `json:"estimated-duration"`
+}
+
+// Matrix is a CI matrix with an entry for each top-level suite
+type Matrix struct {
+	Include []*MatrixEntry `json:"include"`
+}
+
+// GenerateMatrix generates a matrix entry for each suite that is not included by any other suite.
+// If roots are passed, only the suites with matching paths are used.
+func (g *Generator) GenerateMatrix(suites []*Suite, roots ...string) *Matrix {
+	var result = &Matrix{Include: []*MatrixEntry{}}
+	for _, root := range rootSuites(suites, roots...) {
+		var labels []string
+		var duration time.Duration
+		var visited = map[*Suite]struct{}{}
+		var visit func(s *Suite, required bool)
+		visit = func(s *Suite, required bool) {
+			if _, ok := visited[s]; ok {
+				return
+			}
+			visited[s] = struct{}{}
+			duration += s.FrontMatter.EstimatedDuration
+			for _, t := range s.Tests {
+				duration += t.FrontMatter.EstimatedDuration
+			}
+			if !required {
+				labels = mergeLabels(labels, s.Labels)
+				for _, t := range s.Tests {
+					labels = mergeLabels(labels, t.Labels)
+				}
+			}
+			for _, p := range s.Parents {
+				visit(p, true)
+			}
+			for _, child := range s.Children {
+				visit(child, required)
+			}
+		}
+		visit(root, false)
+		sort.Strings(labels)
+		if labels == nil {
+			labels = []string{}
+		}
+
+		result.Include = append(result.Include, &MatrixEntry{
+			Suite:             root.Path,
+			Package:           root.Pkg(),
+			Tags:              labels,
+			EstimatedDuration: duration.String(),
+		})
+	}
+	return result
+}
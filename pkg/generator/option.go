@@ -0,0 +1,34 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// Option is an option for the Generator
+type Option func(g *Generator)
+
+// WithTarget sets the target of the generated suites, e.g. config.TargetBash. Go suites are generated by default
+func WithTarget(target string) Option {
+	return func(g *Generator) {
+		g.target = target
+	}
+}
+
+// WithTemplates sets templates for the generated suites
+func WithTemplates(templates *Templates) Option {
+	return func(g *Generator) {
+		g.templates = templates
+	}
+}
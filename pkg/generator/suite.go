@@ -0,0 +1,738 @@
+// Copyright (c) 2020-2023 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2022-2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+const suiteTemplate = `// Code generated by gotestmd DO NOT EDIT.
+{{ if .Suite.BuildTags }}
+//go:build {{ .Suite.BuildTags }}
+{{ end }}
+// Package {{ .Name }} contains a suite generated from {{ .Suite.Source }}
+{{ if .Metadata }}//
+{{ .Metadata }}{{ end }}package {{ .Name }}
+
+import(
+	{{ .Imports }}
+)
+
+type Suite struct {
+	{{ .Fields }}
+}
+
+func (s *Suite) SetupSuite() {
+	{{ .Checks }}
+	{{ .Setup }}
+	{{ if or .Run .Cleanup .Diagnostics }}
+	r := {{ .Runner }}
+	{{ end }}
+	{{ .Cleanup }}
+	{{ .Diagnostics }}
+	{{ .Run }}
+
+{{ if .TestIncludedSuites }}
+	s.RunIncludedSuites()
+}
+
+func (s *Suite) RunIncludedSuites() {
+	{{ .TestIncludedSuites }}
+{{ end }}
+}
+{{ .Tests }}`
+
+const includedSuiteTemplate = `
+	{{ range .Suites }}
+		s.Run("{{ .Title }}", func() {
+			{{ if .Labels }}s.SkipUnlessLabels({{ .Labels }}){{ end }}
+			{{ if .Parallel }}suite.Run(s.Parallel().T(), &s.{{ .Name }}Suite){{ else }}suite.Run(s.T(), &s.{{ .Name }}Suite){{ end }}
+		})
+	{{ end }}
+`
+
+// Body represents a body of the method
+type Body []*parser.Step
+
+// NewBody creates a body from the scripts
+func NewBody(scripts ...string) Body {
+	return parser.NewSteps(scripts...)
+}
+
+// Scripts returns scripts of the steps of the body
+func (b Body) Scripts() []string {
+	return parser.Scripts(b)
+}
+
+// String returns the body as part of the method
+func (b Body) String() string {
+	var sb strings.Builder
+
+	if len(b) == 0 {
+		return ""
+	}
+
+	for _, step := range b {
+		if step.Annotations.Bool("background") {
+			writeCall(&sb, "r.Background", step)
+			writeScript(&sb, step.Script)
+			sb.WriteString(", ")
+			writeScript(&sb, step.Annotations.Get("ready"))
+			sb.WriteString(")\n")
+		} else {
+			writeCall(&sb, "r.Run", step)
+			writeScript(&sb, step.Script)
+			sb.WriteString(")\n")
+		}
+		if step.Undo != nil {
+			writeCall(&sb, "r.Undo", step.Undo)
+			writeScript(&sb, step.Undo.Script)
+			sb.WriteString(")\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// OnFailureString returns the body as steps that are run by the runner if the test fails
+func (b Body) OnFailureString() string {
+	var sb strings.Builder
+
+	if len(b) == 0 {
+		return ""
+	}
+
+	sb.WriteString("r.OnFailure(\n")
+	for _, step := range b {
+		writeScript(&sb, step.Script)
+		sb.WriteString(",\n")
+	}
+	sb.WriteString(")\n")
+
+	return sb.String()
+}
+
+// writeCall writes the beginning of the runner method call. Steps with known locations are passed to the At variant of the method
+func writeCall(sb *strings.Builder, method string, step *parser.Step) {
+	sb.WriteString(method)
+	if location := step.Location(); location != "" {
+		sb.WriteString("At(")
+		sb.WriteString(strconv.Quote(location))
+		sb.WriteString(", ")
+		return
+	}
+	sb.WriteString("(")
+}
+
+func writeScript(sb *strings.Builder, script string) {
+	var lines = strings.Split(script, "\n")
+	for i, line := range lines {
+		sb.WriteString("`")
+		sb.WriteString(line)
+		sb.WriteString("`")
+		if i+1 < len(lines) {
+			sb.WriteString("+\"\\n\"+")
+		}
+	}
+}
+
+// BashString returns the body as a bash script for the suite
+func (b Body) BashString(withExit, retry bool) string {
+	return b.bashString(withExit, retry, false)
+}
+
+// bashString returns the body as a bash script. With undo, undo steps of the done steps are pushed to undo_stack
+func (b Body) bashString(withExit, retry, undo bool) string {
+	var sb strings.Builder
+
+	if len(b) == 0 {
+		return "\t:\n"
+	}
+
+	for _, step := range b {
+		sb.WriteString("\t")
+		if retry {
+			sb.WriteString("try_run ")
+			sb.WriteString(bashQuote(step.Script))
+		} else {
+			sb.WriteString(step.Script)
+		}
+		sb.WriteString("\n")
+		if withExit {
+			sb.WriteString("\t[ $? = 0 ] || exit 1\n")
+		}
+		if undo && step.Undo != nil {
+			sb.WriteString("\tundo_stack+=(")
+			sb.WriteString(bashQuote(step.Undo.Script))
+			sb.WriteString(")\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// HasUndo returns true if any step of the body has an undo step
+func (b Body) HasUndo() bool {
+	for _, step := range b {
+		if step.Undo != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Undo returns undo steps of the body in reverse order
+func (b Body) Undo() Body {
+	var result Body
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i].Undo != nil {
+			result = append(result, b[i].Undo)
+		}
+	}
+	return result
+}
+
+// withUndoDir returns a copy of the body where undo steps change the dir first, so they can be run from any dir
+func (b Body) withUndoDir(dir string) Body {
+	var result Body
+	for _, step := range b {
+		if step.Undo != nil {
+			undo := *step.Undo
+			undo.Script = "cd " + dir + "\n" + undo.Script
+			copied := *step
+			copied.Undo = &undo
+			step = &copied
+		}
+		result = append(result, step)
+	}
+	return result
+}
+
+// withBackground returns a copy of the body where background steps start jobs in their own process groups and wait for readiness probes.
+// The jobs are stopped by undo steps, so they are stopped with the suite or the test
+func (b Body) withBackground(dir string) Body {
+	var result Body
+	for _, step := range b {
+		if step.Annotations.Bool("background") {
+			step = backgroundStep(dir, step)
+		}
+		result = append(result, step)
+	}
+	return result
+}
+
+// backgroundStep converts the background step into a bash step. Pid of the job is saved to a file unique for the dir and the script
+func backgroundStep(dir string, step *parser.Step) *parser.Step {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(dir + "\n" + step.Script))
+	pidFile := fmt.Sprintf(`"${TMPDIR:-/tmp}/gotestmd-background-%x.pid"`, h.Sum32())
+	stop := "[ -f " + pidFile + " ] && kill -- -\"$(cat " + pidFile + ")\" 2>/dev/null\nrm -f " + pidFile
+
+	var sb strings.Builder
+	sb.WriteString(stop + "\nset -m\n{\n" + step.Script + "\n} &\necho $! >" + pidFile + "\nset +m")
+	if ready := step.Annotations.Get("ready"); ready != "" {
+		sb.WriteString("\nready_deadline=$(($(date -u +%s) + ${RETRY_TIMEOUT_SECONDS:-60}))")
+		sb.WriteString("\nuntil { " + ready + "\n} >/dev/null 2>&1; do")
+		sb.WriteString("\n\tkill -0 -- -\"$(cat " + pidFile + ")\" 2>/dev/null && [ \"$(date -u +%s)\" -lt \"$ready_deadline\" ] || break")
+		sb.WriteString("\n\tsleep 1\ndone\n" + ready)
+	}
+
+	undo := &parser.Step{Script: stop}
+	if step.Undo != nil {
+		undo.Script += "\n" + step.Undo.Script
+	}
+	return &parser.Step{Script: sb.String(), Undo: undo}
+}
+
+// Suite represents a template for generating a testify suite.Suite
+type Suite struct {
+	Dir      string
+	Location string
+	Path     string
+	Dependency
+	Cleanup       Body
+	Run           Body
+	Diagnostics   Body
+	Tests         []*Test
+	Children      []*Suite
+	Parents       []*Suite
+	Deps          Dependencies
+	DepsToSetup   Dependencies
+	Timeout       time.Duration
+	RetryInterval time.Duration
+	BuildTags     string
+	Source        string
+	// EnvFile is loaded by the suite and its tests, relative to the working dir. Go suites load it relative to the module root
+	EnvFile     string
+	FrontMatter parser.FrontMatter
+	// Labels contains labels of the suite and the suites including it
+	Labels    []string
+	labeled   bool
+	templates *Templates
+	// globalBuildTags is the build constraint of all generated suites
+	globalBuildTags string
+}
+
+func (s *Suite) getTemplates() *Templates {
+	if s.templates == nil {
+		s.templates = DefaultTemplates()
+	}
+	return s.templates
+}
+
+func (s *Suite) generateChildrenTesting() string {
+	tmpl, err := template.New("test").Parse(includedSuiteTemplate)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	type suiteData struct {
+		Title    string
+		Name     string
+		Labels   string
+		Parallel bool
+	}
+
+	if len(s.Children) == 0 {
+		return ""
+	}
+
+	var suites []*suiteData
+	for _, child := range s.Children {
+		_, title := path.Split(child.Dir)
+		if child.FrontMatter.Name != "" {
+			title = child.FrontMatter.Name
+		}
+		title = cases.Title(language.Und, cases.NoLower).String(nameRegex.ReplaceAllString(title, "_"))
+		suite := &suiteData{
+			Title:    title,
+			Name:     child.Name(),
+			Parallel: child.FrontMatter.Parallel,
+		}
+		if child.labeled {
+			suite.Labels = labelSetsString(child.labelSets()...)
+		}
+
+		suites = append(suites, suite)
+	}
+
+	var result = new(strings.Builder)
+	err = tmpl.Execute(result, struct {
+		Suites []*suiteData
+	}{
+		Suites: suites,
+	})
+	if err != nil {
+		panic(err.Error())
+	}
+	return result.String()
+}
+
+func (s *Suite) usesTime() bool {
+	if len(s.Run)+len(s.Cleanup)+len(s.Diagnostics) > 0 && usesTime(s.Timeout, s.FrontMatter.Retry) {
+		return true
+	}
+	for _, test := range s.Tests {
+		if len(test.Run)+len(test.Cleanup)+len(test.Diagnostics) > 0 && usesTime(test.Timeout, test.FrontMatter.Retry) {
+			return true
+		}
+	}
+	return false
+}
+
+func usesTime(timeout time.Duration, retry *parser.Retry) bool {
+	return timeout > 0 || retry != nil && retry.Interval > 0
+}
+
+// String returns a string that contains generated testify.Suite
+func (s *Suite) String() string {
+	result, _ := s.Render()
+	return result
+}
+
+// loadsEnv returns true if the suite or any of its tests has env variables
+func (s *Suite) loadsEnv() bool {
+	if s.EnvFile != "" || len(s.FrontMatter.Env) > 0 {
+		return true
+	}
+	for _, t := range s.Tests {
+		if len(t.FrontMatter.Env) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns a string that contains generated testify.Suite or an error if the template can't be executed
+func (s *Suite) Render() (string, error) {
+	cleanup := s.Cleanup.String()
+	if len(cleanup) > 0 {
+		cleanup = fmt.Sprintf(`	s.Cleanup(func() {
+		%v
+	})`, cleanup)
+	}
+
+	imports := s.Deps.String()
+	if s.usesTime() {
+		imports = "\"time\"\n" + imports
+	}
+
+	if len(s.Tests) == 0 {
+		s.Tests = append(s.Tests, &Test{templates: s.templates})
+	}
+
+	var loadEnv, envFile string
+	if s.EnvFile != "" {
+		var err error
+		if envFile, err = modulePath(s.EnvFile); err != nil {
+			return "", err
+		}
+	}
+	if s.loadsEnv() {
+		loadEnv = goLoadEnv(envFile, s.FrontMatter)
+	}
+
+	var tests = new(strings.Builder)
+	for _, test := range s.Tests {
+		if loadEnv != "" {
+			// each test loads the env, so env variables of the test don't leak to the next tests
+			test.loadEnv = goLoadEnv(envFile, s.FrontMatter, test.FrontMatter)
+		}
+		t, err := test.Render()
+		if err != nil {
+			return "", err
+		}
+		_, _ = tests.WriteString(t)
+	}
+
+	var result = new(strings.Builder)
+
+	err := s.getTemplates().Suite.Execute(result, &SuiteData{
+		Suite:              s,
+		Dir:                s.Dir,
+		Name:               s.Name(),
+		Runner:             runnerString(s.Dir, s.Timeout, s.FrontMatter.Retry),
+		Metadata:           metadata("//", s.FrontMatter),
+		Checks:             goChecks(s.FrontMatter, loadEnv),
+		Cleanup:            cleanup,
+		Diagnostics:        s.Diagnostics.OnFailureString(),
+		Run:                s.Run.String(),
+		Imports:            imports,
+		Fields:             s.Deps.FieldsString(),
+		Setup:              s.DepsToSetup.SetupString(),
+		TestIncludedSuites: s.generateChildrenTesting(),
+		Tests:              tests.String(),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot generate suite %v", s.Name())
+	}
+
+	return normalizeSpaces(result.String()), nil
+}
+
+const bashSuiteTemplate = `#!/usr/bin/env bash
+{{ .Metadata }}{{ .RetryFunction }}{{ if .Undo }}
+undo_stack=()
+
+# undo runs undo steps of the done steps in reverse order. Steps pushed before the passed stack size are kept
+undo() {
+	local undo_index
+	for ((undo_index = ${#undo_stack[@]} - 1; undo_index >= ${1:-0}; undo_index--)); do
+		eval "${undo_stack[undo_index]}"
+	done
+	undo_stack=("${undo_stack[@]:0:${1:-0}}")
+}
+{{ end }}{{ .Env }}
+setup_dependencies() {
+{{ if .Undo }}	setup_started=1
+{{ end }}{{ .SetupDependencies }}}
+
+setup_main() {
+{{ if .Undo }}	setup_started=1
+{{ end }}{{ .SetupMain }}}
+
+setup() {
+{{ .Checks }}	setup_dependencies && setup_main
+}
+
+cleanup_dependencies() {
+{{ .CleanupDependencies }}	# cleanup shouldn't report errors
+	true
+}
+
+cleanup_main() {
+{{ .CleanupMain }}	# cleanup shouldn't report errors
+	true
+}
+
+cleanup() {
+{{- if .Undo }}
+	# all undo steps are run if the setup wasn't run by this process
+	[ -n "$setup_started" ] || undo_stack=(
+{{ .UndoSteps }}	)
+	undo
+{{- end }}
+	cleanup_main
+	cleanup_dependencies
+}
+{{ .Tests }}
+
+list() {
+{{- range .Suite.Tests }}
+	echo test{{ .Name }}
+{{- end }}
+	true
+}
+
+all() {
+	trap cleanup EXIT
+	setup || exit 1
+{{- range .Suite.Tests }}
+	test{{ .Name }} || exit 1
+{{- end }}
+}
+
+usage() {
+	cat <<EOF
+Usage: $(basename "$0") COMMAND
+
+Suite generated from {{ .Suite.Source }}
+
+Commands:
+  setup                 set up the suite and its dependencies
+  setup_dependencies    set up the suites required by the suite
+  setup_main            set up the suite
+  cleanup               clean up the suite and its dependencies
+  cleanup_dependencies  clean up the suites required by the suite
+  cleanup_main          clean up the suite
+  list                  list tests of the suite
+  all                   run setup, all tests and cleanup. Cleanup runs even if setup or a test fails
+  TEST                  run the test, see list
+  help, -h, --help      show this help
+EOF
+}
+
+case "$1" in
+{{- if .Undo }}
+setup | setup_dependencies | setup_main{{ range .Suite.Tests }} | test{{ .Name }}{{ end }})
+	# done steps are undone if the command fails
+	trap undo EXIT
+	"$1" && trap - EXIT
+	;;
+cleanup | cleanup_dependencies | cleanup_main | list | all)
+{{- else }}
+setup | setup_dependencies | setup_main | cleanup | cleanup_dependencies | cleanup_main | list | all{{ range .Suite.Tests }} | test{{ .Name }}{{ end }})
+{{- end }}
+	"$1"
+	;;
+help | -h | --help)
+	usage
+	;;
+"")
+	usage >&2
+	exit 1
+	;;
+*)
+	echo "unknown command: $1" >&2
+	usage >&2
+	exit 1
+	;;
+esac
+`
+
+const retryTemplate = `
+function try_run() {
+    command="$1"
+    attempt=0
+    retry_interval="${RETRY_INTERVAL:-{{ .Interval }}}"
+    timeout="${RETRY_TIMEOUT_SECONDS:-{{ .Timeout }}}"
+    max_attempts="${RETRY_ATTEMPTS:-0}"
+    start_time="$(date -u +%s)"
+    echo "===== next command ====="
+    echo "$command"
+    while true; do
+        attempt=$((attempt + 1))
+        echo "===== attempt $attempt ====="
+        echo "current time $(date +"%Y-%m-%dT%H:%M:%S%z")"
+        source /dev/stdin <<<"$(echo "${command}")"
+        retval=$?
+		echo
+        echo "retval = $retval"
+        current_time="$(date -u +%s)"
+        elapsed=$((current_time-start_time))
+        echo "elapsed = $elapsed"
+        [ $retval = 0 ] && echo "===== command success =====" && return 0
+        [ "$elapsed" -gt "$timeout" ] && echo "===== command timed out =====" && return 1
+        [ "$max_attempts" -gt 0 ] && [ "$attempt" -ge "$max_attempts" ] && echo "===== attempts exhausted =====" && return 1
+        sleep $retry_interval
+    done
+}
+`
+
+// BashString generates bash script for the suite
+func (s *Suite) BashString(retry bool) string {
+	result, _ := s.RenderBash(retry)
+	return result
+}
+
+// RenderBash generates bash script for the suite or returns an error if the template can't be executed
+func (s *Suite) RenderBash(retry bool) (string, error) {
+	setupDependencies := s.getDependenciesSetup()
+	cleanupDependencies := s.getDependenciesCleanup()
+
+	// the suite is left unchanged, so suites requiring it get its original steps
+	absDir, _ := filepath.Abs(s.Dir)
+	run := append(NewBody(fmt.Sprintf("echo 'setup suite %s'", filepath.Dir(s.Location)), "cd "+absDir), s.Run.withBackground(absDir).withUndoDir(absDir)...)
+	cleanup := append(NewBody(fmt.Sprintf("echo 'cleanup suite %s'", filepath.Dir(s.Location)), "cd "+absDir), s.Cleanup...)
+
+	var undoSteps strings.Builder
+	var undo = setupDependencies.HasUndo() || run.HasUndo()
+	for _, step := range append(append(Body{}, setupDependencies...), run...) {
+		if step.Undo != nil {
+			_, _ = fmt.Fprintf(&undoSteps, "\t\t%v\n", bashQuote(step.Undo.Script))
+		}
+	}
+
+	var tests = new(strings.Builder)
+	for _, test := range s.Tests {
+		undo = undo || test.Run.HasUndo()
+		test.suiteChecks = bashChecks(s.FrontMatter, "return 0")
+		t, err := test.RenderBash(retry)
+		if err != nil {
+			return "", err
+		}
+		tests.WriteString(t)
+	}
+
+	var env string
+	if s.loadsEnv() {
+		// tests check preset_env even if the suite has no env variables
+		env = bashPresetEnv + bashEnvFile(s.EnvFile) + bashEnv(s.FrontMatter.Env, "export")
+	}
+
+	retryFunction, retryPolicy := "", ""
+	if retry {
+		retryFunction = s.retryFunction()
+		retryPolicy = bashRetryPolicy(s.FrontMatter)
+	}
+
+	var result = new(strings.Builder)
+	err := s.getTemplates().BashSuite.Execute(result, &BashSuiteData{
+		Suite:               s,
+		Dir:                 absDir,
+		Metadata:            metadata("#", s.FrontMatter),
+		Env:                 env,
+		Checks:              bashChecks(s.FrontMatter, "return 0"),
+		SetupDependencies:   setupDependencies.bashString(true, retry, true),
+		SetupMain:           retryPolicy + run.bashString(true, retry, true),
+		CleanupDependencies: cleanupDependencies.BashString(false, false),
+		CleanupMain:         cleanup.BashString(false, false),
+		RetryFunction:       retryFunction,
+		Undo:                undo,
+		UndoSteps:           undoSteps.String(),
+		Tests:               tests.String(),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot generate bash suite %v", s.Name())
+	}
+
+	return result.String(), nil
+}
+
+func (s *Suite) retryFunction() string {
+	tmpl, err := template.New("retry").Parse(retryTemplate)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	interval, timeout := time.Second, 300*time.Second
+	if s.RetryInterval > 0 {
+		interval = s.RetryInterval
+	}
+	if s.Timeout > 0 {
+		timeout = s.Timeout
+	}
+
+	var result = new(strings.Builder)
+	_ = tmpl.Execute(result, struct {
+		Interval string
+		Timeout  string
+	}{
+		Interval: secondsString(interval),
+		Timeout:  strconv.Itoa(int(math.Ceil(timeout.Seconds()))),
+	})
+	return result.String()
+}
+
+// requiredSuites returns all suites required by s in topological order. Each suite occurs once
+func (s *Suite) requiredSuites() []*Suite {
+	var result []*Suite
+	var visited = map[*Suite]struct{}{s: {}}
+	var visit func(*Suite)
+	visit = func(current *Suite) {
+		for _, p := range current.Parents {
+			if _, ok := visited[p]; ok {
+				continue
+			}
+			visited[p] = struct{}{}
+			visit(p)
+			result = append(result, p)
+		}
+	}
+	visit(s)
+	return result
+}
+
+// getDependenciesSetup returns setup steps of the required suites
+func (s *Suite) getDependenciesSetup() Body {
+	var setup Body
+	for _, p := range s.requiredSuites() {
+		absDir, _ := filepath.Abs(p.Dir)
+		setup = append(setup, NewBody(fmt.Sprintf("echo 'setup suite %s'", filepath.Dir(p.Location)), "cd "+absDir)...)
+		setup = append(setup, p.Run.withBackground(absDir).withUndoDir(absDir)...)
+	}
+	return setup
+}
+
+// getDependenciesCleanup returns cleanup steps of the required suites in reverse setup order
+func (s *Suite) getDependenciesCleanup() Body {
+	var cleanup Body
+	required := s.requiredSuites()
+	for i := len(required) - 1; i >= 0; i-- {
+		p := required[i]
+		absDir, _ := filepath.Abs(p.Dir)
+		cleanup = append(cleanup, NewBody(fmt.Sprintf("echo 'cleanup suite %s'", filepath.Dir(p.Location)), "cd "+absDir)...)
+		cleanup = append(cleanup, p.Cleanup...)
+	}
+	return cleanup
+}
@@ -0,0 +1,366 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/bash"
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+	"github.com/networkservicemesh/gotestmd/pkg/generator"
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+	"github.com/networkservicemesh/gotestmd/pkg/pipeline"
+)
+
+func newSuite(name string, parents ...*generator.Suite) *generator.Suite {
+	return &generator.Suite{
+		Dir:        name,
+		Path:       name,
+		Location:   "out/" + name + "/suite.gen.sh",
+		Dependency: generator.Dependency("example/" + name),
+		Run:        generator.NewBody("echo setup " + name),
+		Cleanup:    generator.NewBody("echo cleanup " + name),
+		Parents:    parents,
+	}
+}
+
+// bashFunction returns echo commands of the bash function
+func bashFunction(t *testing.T, script, name string) []string {
+	body := regexp.MustCompile(`(?s)\n` + name + `\(\) \{\n(.*?)\n\}`).FindStringSubmatch(script)
+	require.Len(t, body, 2, name)
+	var result []string
+	for _, line := range strings.Split(body[1], "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "echo ") {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func TestBashDependenciesCleanupOrder(t *testing.T) {
+	producer := newSuite("producer")
+	consumer1 := newSuite("consumer1", producer)
+	consumer2 := newSuite("consumer2", consumer1)
+
+	script, err := consumer2.RenderBash(false)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"echo 'setup suite out/producer'",
+		"echo setup producer",
+		"echo 'setup suite out/consumer1'",
+		"echo setup consumer1",
+	}, bashFunction(t, script, "setup_dependencies"))
+
+	require.Equal(t, []string{
+		"echo 'cleanup suite out/consumer1'",
+		"echo cleanup consumer1",
+		"echo 'cleanup suite out/producer'",
+		"echo cleanup producer",
+	}, bashFunction(t, script, "cleanup_dependencies"))
+}
+
+func TestBashDependenciesSetupOnce(t *testing.T) {
+	producer := newSuite("producer")
+	consumer1 := newSuite("consumer1", producer)
+	consumer2 := newSuite("consumer2", producer)
+	consumer3 := newSuite("consumer3", consumer1, consumer2)
+
+	script, err := consumer3.RenderBash(false)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"echo 'setup suite out/producer'",
+		"echo setup producer",
+		"echo 'setup suite out/consumer1'",
+		"echo setup consumer1",
+		"echo 'setup suite out/consumer2'",
+		"echo setup consumer2",
+	}, bashFunction(t, script, "setup_dependencies"))
+
+	require.Equal(t, []string{
+		"echo 'cleanup suite out/consumer2'",
+		"echo cleanup consumer2",
+		"echo 'cleanup suite out/consumer1'",
+		"echo cleanup consumer1",
+		"echo 'cleanup suite out/producer'",
+		"echo cleanup producer",
+	}, bashFunction(t, script, "cleanup_dependencies"))
+}
+
+func TestBashDependenciesCleanupExamples(t *testing.T) {
+	conf, err := config.FromArgs([]string{"../../examples", "out"})
+	require.NoError(t, err)
+	conf.Targets = []string{config.TargetBash}
+
+	p := pipeline.New(conf)
+	examples, err := p.Parse()
+	require.NoError(t, err)
+	linkedExamples, err := p.Link(examples...)
+	require.NoError(t, err)
+	suites, err := p.Generate(config.TargetBash, linkedExamples...)
+	require.NoError(t, err)
+
+	var consumers int
+	for _, s := range suites {
+		if !strings.HasPrefix(s.Path, "producer/") {
+			continue
+		}
+		consumers++
+		script, err := s.RenderBash(false)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{
+			"echo 'cleanup suite out/producer'",
+			`echo "Do teardown logic for the suite here"`,
+		}, bashFunction(t, script, "cleanup_dependencies"), s.Path)
+	}
+	require.NotZero(t, consumers)
+}
+
+func TestSuiteDiagnostics(t *testing.T) {
+	s := newSuite("producer")
+	s.Diagnostics = generator.NewBody("kubectl get pods", "kubectl logs\n-l app=producer")
+	require.Equal(t, []string{"kubectl get pods", "kubectl logs\n-l app=producer"}, s.Diagnostics.Scripts())
+	s.Tests = []*generator.Test{{
+		Name:        "Consumer",
+		Diagnostics: generator.NewBody("kubectl describe pods"),
+	}}
+
+	result, err := s.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "r.OnFailure(\n`kubectl get pods`,\n`kubectl logs`+\"\\n\"+`-l app=producer`,\n)\nr.Run(`echo setup producer`)")
+	require.Contains(t, result, "func (s *Suite) TestConsumer() {\nr := s.Runner(\"\")\nr.OnFailure(\n`kubectl describe pods`,\n)\n}")
+}
+
+func TestSuiteStepLocations(t *testing.T) {
+	s := newSuite("producer")
+	s.Run = generator.Body{
+		{Script: "kubectl apply -k .", File: "examples/producer/README.md", Line: 10, Undo: &parser.Step{Script: "kubectl delete -k .", File: "examples/producer/README.md", Line: 14}},
+		{Script: "kubectl apply -k .", File: "examples/producer/README.md", Line: 20},
+		{Script: "sleep 1000", File: "examples/producer/README.md", Line: 24, Annotations: parser.Annotations{"background": ""}},
+	}
+
+	result, err := s.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "r.RunAt(\"examples/producer/README.md:10\", `kubectl apply -k .`)\nr.UndoAt(\"examples/producer/README.md:14\", `kubectl delete -k .`)\n")
+	require.Contains(t, result, "r.RunAt(\"examples/producer/README.md:20\", `kubectl apply -k .`)\n")
+	require.Contains(t, result, "r.BackgroundAt(\"examples/producer/README.md:24\", `sleep 1000`, ``)\n")
+}
+
+func TestSuiteUndo(t *testing.T) {
+	dir := t.TempDir()
+	newUndoSuite := func(failing string) *generator.Suite {
+		s := newSuite("producer")
+		s.Dir = dir
+		s.Run = generator.NewBody("echo create 1", "echo create 2", failing)
+		s.Run[0].Undo = generator.NewBody("echo delete 1")[0]
+		s.Run[1].Undo = generator.NewBody("echo delete 2")[0]
+		s.Tests = []*generator.Test{{Name: "Consumer", Dir: dir, Run: generator.NewBody("echo consume")}}
+		s.Tests[0].Run[0].Undo = generator.NewBody("echo unconsume")[0]
+		return s
+	}
+
+	result, err := newUndoSuite("true").Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "r.Run(`echo create 1`)\nr.Undo(`echo delete 1`)\nr.Run(`echo create 2`)\nr.Undo(`echo delete 2`)")
+
+	runner, err := bash.New(bash.WithDir(dir))
+	require.NoError(t, err)
+	defer runner.Close()
+	run := func(s *generator.Suite, command string) []string {
+		script, err := s.RenderBash(false)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "suite.gen.sh"), []byte(script), 0o600))
+		stdout, _, _, err := runner.Run("bash suite.gen.sh " + command)
+		require.NoError(t, err)
+		var result []string
+		for _, line := range strings.Split(stdout, "\n") {
+			if strings.HasPrefix(line, "create") || strings.HasPrefix(line, "delete") || strings.Contains(line, "consume") || line == "cleanup producer" {
+				result = append(result, line)
+			}
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"create 1", "create 2", "consume", "unconsume", "delete 2", "delete 1", "cleanup producer"}, run(newUndoSuite("true"), "all"))
+	require.Equal(t, []string{"create 1", "create 2", "delete 2", "delete 1", "cleanup producer"}, run(newUndoSuite("false"), "all"))
+	require.Equal(t, []string{"create 1", "create 2"}, run(newUndoSuite("true"), "setup"))
+	require.Equal(t, []string{"create 1", "create 2", "delete 2", "delete 1"}, run(newUndoSuite("false"), "setup"))
+	require.Equal(t, []string{"delete 2", "delete 1", "cleanup producer"}, run(newUndoSuite("false"), "cleanup"))
+}
+
+func TestSuiteParallel(t *testing.T) {
+	s := newSuite("producer")
+	child := newSuite("consumer")
+	child.FrontMatter.Parallel = true
+	s.Children = []*generator.Suite{child}
+	s.Tests = []*generator.Test{{
+		Name:        "Consumer",
+		Run:         generator.NewBody("echo consumer"),
+		FrontMatter: parser.FrontMatter{Parallel: true},
+	}}
+
+	result, err := s.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "s.Run(\"Consumer\", func() {\nsuite.Run(s.Parallel().T(), &s.consumerSuite)\n})")
+	require.Contains(t, result, "func (s *Suite) TestConsumer() {\n{\ns := s.Parallel()\nr := s.Runner(\"\")\nr.Run(`echo consumer`)\n}\n}")
+}
+
+func TestEntryPointParallel(t *testing.T) {
+	producer := newSuite("producer")
+	var consumers []*generator.Suite
+	for _, name := range []string{"consumer1", "consumer2", "standalone", "sequential"} {
+		consumer := newSuite(name, producer)
+		consumer.FrontMatter.Parallel = name != "sequential"
+		consumers = append(consumers, consumer)
+	}
+	consumers[2].Parents = nil
+
+	result := (&generator.EntryPoint{Name: "suites", Suites: consumers}).String()
+	require.Contains(t, result, "var branches [1]sync.Mutex")
+	require.Contains(t, result, "t.Run(\"consumer1\", func(t *testing.T) {\n\t\tt.Parallel()\n\t\tbranches[0].Lock()\n\t\tt.Cleanup(branches[0].Unlock)\n")
+	require.Contains(t, result, "t.Run(\"consumer2\", func(t *testing.T) {\n\t\tt.Parallel()\n\t\tbranches[0].Lock()\n")
+	require.Contains(t, result, "t.Run(\"standalone\", func(t *testing.T) {\n\t\tt.Parallel()\n\t\tsuite.Run(")
+	require.Contains(t, result, "t.Run(\"sequential\", func(t *testing.T) {\n\t\tsuite.Run(")
+}
+
+func TestSuiteSetupRequired(t *testing.T) {
+	producer := newSuite("producer")
+	consumer := newSuite("consumer", producer)
+	consumer.DepsToSetup = generator.Dependencies{"example/shell", "example/producer"}
+
+	result, err := consumer.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "parents := []interface{}{&s.Suite}\n")
+	require.Contains(t, result, "s.SetupRequired(&s.producerSuite)\nr := s.Runner(\"consumer\")\ns.Cleanup(func() {\nr.Run(`echo cleanup consumer`)\n})")
+
+	entryPoint := &generator.EntryPoint{Name: "suites", Suites: []*generator.Suite{consumer}}
+	require.Contains(t, entryPoint.String(), "func TestEntryPoint(t *testing.T) {\n\t// required suites are set up once and cleaned up when all suites finish\n\tshell.ShareSetup(t)\n")
+
+	entryPoint.Suites = []*generator.Suite{producer}
+	require.NotContains(t, entryPoint.String(), "ShareSetup")
+}
+
+func TestSuiteBackground(t *testing.T) {
+	dir := t.TempDir()
+	s := newSuite("producer")
+	s.Dir = dir
+	s.Run = generator.NewBody("sleep 0.2; touch ready; while true; do date >>ticks; sleep 0.01; done", "echo started")
+	s.Run[0].Annotations = parser.Annotations{"background": "true", "ready": "[ -f ready ]"}
+	s.Run[0].Undo = generator.NewBody("echo undo")[0]
+
+	result, err := s.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "r.Background(`sleep 0.2; touch ready; while true; do date >>ticks; sleep 0.01; done`, `[ -f ready ]`)\nr.Undo(`echo undo`)\nr.Run(`echo started`)")
+
+	script, err := s.RenderBash(false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "suite.gen.sh"), []byte(script), 0o600))
+	runner, err := bash.New(bash.WithDir(dir))
+	require.NoError(t, err)
+	defer runner.Close()
+
+	stdout, _, exitCode, err := runner.Run("bash suite.gen.sh setup")
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode, stdout)
+	require.Contains(t, stdout, "started")
+	_, err = os.Stat(filepath.Join(dir, "ready"))
+	require.NoError(t, err)
+
+	stdout, _, _, err = runner.Run("bash suite.gen.sh cleanup")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "undo")
+
+	// the job is stopped by the cleanup
+	ticks, err := os.ReadFile(filepath.Clean(filepath.Join(dir, "ticks")))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	stopped, err := os.ReadFile(filepath.Clean(filepath.Join(dir, "ticks")))
+	require.NoError(t, err)
+	require.Equal(t, string(ticks), string(stopped))
+}
+
+func TestSuiteEnv(t *testing.T) {
+	s := newSuite("producer")
+	s.EnvFile = ".env"
+	s.FrontMatter = parser.FrontMatter{Env: map[string]string{"NAMESPACE": "ns", "NAME": "producer"}, RequiresEnv: []string{"KUBECONFIG"}}
+	s.Tests = []*generator.Test{{
+		Name:        "Consumer",
+		Run:         generator.NewBody("echo consumer"),
+		FrontMatter: parser.FrontMatter{Env: map[string]string{"NAME": "consumer"}},
+	}}
+
+	result, err := s.Render()
+	require.NoError(t, err)
+	// the env file is relative to the working dir, go suites load it relative to the module root
+	require.Contains(t, result, "func (s *Suite) SetupSuite() {\ns.LoadEnv(\"pkg/generator/.env\", \"NAME=producer\", \"NAMESPACE=ns\")\ns.RequireEnv(\"KUBECONFIG\")\n")
+	require.Contains(t, result, "func (s *Suite) TestConsumer() {\ns.LoadEnv(\"pkg/generator/.env\", \"NAME=consumer\", \"NAMESPACE=ns\")\n")
+
+	script, err := s.RenderBash(false)
+	require.NoError(t, err)
+	require.Contains(t, script, "\t[ -n \"${preset_env[NAME]}\" ] || export NAME='producer'\n\t[ -n \"${preset_env[NAMESPACE]}\" ] || export NAMESPACE='ns'\n\nsetup_dependencies() {")
+	require.Contains(t, script, "testConsumer() {\n\t[ -n \"${preset_env[NAME]}\" ] || local -x NAME='consumer'\n")
+	require.Contains(t, script, "\t[ -n \"${KUBECONFIG+x}\" ] || { echo 'skip: required env variable KUBECONFIG is not set'; return 0; }\n")
+
+	// env variables of the test override env variables of the suite, both override the env file, the environment overrides all
+	dir := t.TempDir()
+	s = newSuite("producer")
+	s.Dir = dir
+	s.EnvFile = filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(s.EnvFile, []byte("# comment\n\nexport NAMESPACE = 'from file'\nFILE=file\n"), 0o600))
+	s.FrontMatter = parser.FrontMatter{Env: map[string]string{"NAMESPACE": "ns", "NAME": "producer"}}
+	s.Run = generator.NewBody("echo setup NAME=$NAME")
+	s.Tests = []*generator.Test{{
+		Name:        "Consumer",
+		Dir:         dir,
+		Run:         generator.NewBody("echo test NAME=$NAME NAMESPACE=$NAMESPACE FILE=$FILE"),
+		FrontMatter: parser.FrontMatter{Env: map[string]string{"NAME": "consumer"}},
+	}}
+	script, err = s.RenderBash(false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "suite.gen.sh"), []byte(script), 0o600))
+	runner, err := bash.New(bash.WithDir(dir))
+	require.NoError(t, err)
+	defer runner.Close()
+	stdout, _, _, err := runner.Run("bash suite.gen.sh all")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "setup NAME=producer\n")
+	require.Contains(t, stdout, "test NAME=consumer NAMESPACE=ns FILE=file\n")
+	stdout, _, _, err = runner.Run("NAME=process NAMESPACE= bash suite.gen.sh all")
+	require.NoError(t, err)
+	require.Contains(t, stdout, "setup NAME=process\n")
+	require.Contains(t, stdout, "test NAME=process NAMESPACE= FILE=file\n")
+
+	// the env of a test doesn't leak to the next tests
+	s = newSuite("producer")
+	s.Tests = []*generator.Test{
+		{Name: "First", Run: generator.NewBody("echo first"), FrontMatter: parser.FrontMatter{Env: map[string]string{"NAME": "first"}}},
+		{Name: "Second", Run: generator.NewBody("echo second")},
+	}
+	result, err = s.Render()
+	require.NoError(t, err)
+	require.Contains(t, result, "func (s *Suite) SetupSuite() {\ns.LoadEnv(\"\")\n")
+	require.Contains(t, result, "func (s *Suite) TestSecond() {\ns.LoadEnv(\"\")\n")
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+)
+
+// Templates contains text/template templates used for generating suites.
+//
+// Suite is executed with SuiteData, Test with TestData, BashSuite with BashSuiteData and BashTest with BashTestData.
+type Templates struct {
+	Suite     *template.Template
+	Test      *template.Template
+	BashSuite *template.Template
+	BashTest  *template.Template
+}
+
+// SuiteData is a data model of the Go suite template
+type SuiteData struct {
+	// Suite provides steps with annotations, tests, children, parents and dependencies of the suite
+	Suite *Suite
+	// Name is a package name of the suite
+	Name string
+	// Dir is a directory of the markdown example
+	Dir string
+	// Runner is an expression that creates a runner for the Dir
+	Runner string
+	// Metadata contains owners and labels of the example as comment lines
+	Metadata string
+	// Checks skips or fails the suite according to the front matter
+	Checks string
+	// Imports contains imports of the dependencies
+	Imports string
+	// Fields contains fields of the dependencies
+	Fields string
+	// Setup sets up the required suites
+	Setup string
+	// Cleanup registers cleanup steps
+	Cleanup string
+	// Diagnostics registers steps that are run if the suite or its tests fail
+	Diagnostics string
+	// Run runs setup steps
+	Run string
+	// TestIncludedSuites runs the included suites
+	TestIncludedSuites string
+	// Tests contains rendered tests of the suite
+	Tests string
+}
+
+// TestData is a data model of the Go test template
+type TestData struct {
+	// Test provides steps with annotations of the test
+	Test *Test
+	// Name is a name of the test without Test prefix
+	Name string
+	// Dir is a directory of the markdown example
+	Dir string
+	// Runner is an expression that creates a runner for the Dir
+	Runner string
+	// Metadata contains owners and labels of the example as comment lines
+	Metadata string
+	// Checks skips or fails the test according to the front matter
+	Checks string
+	// Cleanup registers cleanup steps
+	Cleanup string
+	// Diagnostics registers steps that are run if the test fails
+	Diagnostics string
+	// Parallel is true if the test is run in parallel with other parallel tests of the suite
+	Parallel bool
+	// Run runs test steps
+	Run string
+}
+
+// BashSuiteData is a data model of the bash suite template
+type BashSuiteData struct {
+	// Suite provides steps with annotations, tests, children, parents and dependencies of the suite
+	Suite *Suite
+	// Dir is an absolute directory of the markdown example
+	Dir string
+	// Metadata contains owners and labels of the example as comment lines
+	Metadata string
+	// Env exports env variables of the front matter that are not set in the environment
+	Env string
+	// Checks skips or fails the setup according to the front matter
+	Checks string
+	// RetryFunction declares try_run function if retry is enabled
+	RetryFunction       string
+	SetupDependencies   string
+	SetupMain           string
+	CleanupDependencies string
+	CleanupMain         string
+	// Undo is true if any step of the suite, its dependencies or tests has an undo step
+	Undo bool
+	// UndoSteps contains quoted undo steps of the suite and its dependencies in setup order
+	UndoSteps string
+	// Tests contains rendered tests of the suite
+	Tests string
+}
+
+// BashTestData is a data model of the bash test template
+type BashTestData struct {
+	// Test provides steps with annotations of the test
+	Test *Test
+	// Name is a name of the test without test prefix
+	Name string
+	// Dir is an absolute directory of the markdown example
+	Dir string
+	// Metadata contains owners and labels of the example as comment lines
+	Metadata string
+	// Checks skips or fails the test according to the front matter of the test and the suite
+	Checks string
+	// Undo is true if any step of the test has an undo step
+	Undo    bool
+	Run     string
+	Cleanup string
+}
+
+// DefaultTemplates returns built-in templates
+func DefaultTemplates() *Templates {
+	return &Templates{
+		Suite:     template.Must(template.New("suite").Parse(suiteTemplate)),
+		Test:      template.Must(template.New("test").Parse(testTemplate)),
+		BashSuite: template.Must(template.New("bashsuite").Parse(bashSuiteTemplate)),
+		BashTest:  template.Must(template.New("bashtest").Parse(bashTestTemplate)),
+	}
+}
+
+// LoadTemplates reads templates from the files set in the config. Built-in templates are used for the rest
+func LoadTemplates(conf config.Templates) (*Templates, error) {
+	result := DefaultTemplates()
+	for _, t := range []struct {
+		path string
+		tmpl **template.Template
+	}{
+		{path: conf.Suite, tmpl: &result.Suite},
+		{path: conf.Test, tmpl: &result.Test},
+		{path: conf.BashSuite, tmpl: &result.BashSuite},
+		{path: conf.BashTest, tmpl: &result.BashTest},
+	} {
+		if t.path == "" {
+			continue
+		}
+		tmpl, err := template.ParseFiles(t.path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot load template %v", t.path)
+		}
+		*t.tmpl = tmpl
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020-2023 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+const testTemplate = `
+{{ .Metadata }}{{ if or .Run .Cleanup .Diagnostics -}}
+func (s *Suite) Test{{ .Name }}() {
+	{{ .Checks }}
+	{{ if .Parallel }}{
+	s := s.Parallel()
+	{{ end }}
+	r := {{ .Runner }}
+	{{ .Cleanup }}
+	{{ .Diagnostics }}
+	{{ .Run }}
+	{{ if .Parallel }}}
+	{{ end }}
+}
+{{- else -}}
+func (s *Suite) Test{{ .Name }}() {}
+{{- end }}
+`
+
+// Test is a template for a test for a suite
+type Test struct {
+	Dir         string
+	Name        string
+	Cleanup     Body
+	Run         Body
+	Diagnostics Body
+	Timeout     time.Duration
+	BuildTags   string
+	FrontMatter parser.FrontMatter
+	// Labels contains labels of the test and the suites including it
+	Labels      []string
+	labeled     bool
+	templates   *Templates
+	suiteChecks string
+	loadEnv     string
+}
+
+func (t *Test) getTemplates() *Templates {
+	if t.templates == nil {
+		t.templates = DefaultTemplates()
+	}
+	return t.templates
+}
+
+// String returns string as a test for the suite
+func (t *Test) String() string {
+	result, _ := t.Render()
+	return result
+}
+
+// Render returns string as a test for the suite or an error if the template can't be executed
+func (t *Test) Render() (string, error) {
+	cleanup := t.Cleanup.String()
+	if len(cleanup) > 0 {
+		cleanup = fmt.Sprintf(`	s.T().Cleanup(func() {
+		%v
+	})`, cleanup)
+	}
+
+	checks := goChecks(t.FrontMatter, t.loadEnv)
+	if t.labeled {
+		checks = fmt.Sprintf("s.SkipUnlessLabels(%v)\n", labelSetsString(t.Labels)) + checks
+	}
+	if t.BuildTags != "" {
+		checks = fmt.Sprintf("if !%v {\ns.T().Skip(%v)\n}\n", testBuildTagsConst(t), skipReason(t.BuildTags)) + checks
+	}
+
+	var result = new(strings.Builder)
+
+	err := t.getTemplates().Test.Execute(result, &TestData{
+		Test:        t,
+		Name:        t.Name,
+		Dir:         t.Dir,
+		Runner:      runnerString(t.Dir, t.Timeout, t.FrontMatter.Retry),
+		Metadata:    metadata("//", t.FrontMatter),
+		Checks:      checks,
+		Cleanup:     cleanup,
+		Diagnostics: t.Diagnostics.OnFailureString(),
+		Parallel:    t.FrontMatter.Parallel,
+		Run:         t.Run.String(),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot generate test %v", t.Name)
+	}
+
+	return result.String(), nil
+}
+
+const bashTestTemplate = `
+{{ .Metadata }}test{{ .Name }}() {
+{{ .Checks }}{{ if .Undo }}	local undo_size=${#undo_stack[@]}
+{{ end }}{{ .Run }}
+{{ if .Undo }}	undo "$undo_size"
+{{ end }}{{ .Cleanup }}}`
+
+// BashString generates a bash script for the test
+func (t *Test) BashString(retry bool) string {
+	result, _ := t.RenderBash(retry)
+	return result
+}
+
+// RenderBash generates a bash script for the test or returns an error if the template can't be executed
+func (t *Test) RenderBash(retry bool) (string, error) {
+	absDir, _ := filepath.Abs(t.Dir)
+
+	run := append(t.Run.withBackground(absDir).withUndoDir(absDir), NewBody("cd "+absDir)...).bashString(true, retry, true)
+	if retry {
+		run = bashRetryPolicy(t.FrontMatter) + run
+	}
+	result := new(strings.Builder)
+
+	err := t.getTemplates().BashTest.Execute(result, &BashTestData{
+		Test:     t,
+		Name:     t.Name,
+		Dir:      absDir,
+		Metadata: metadata("#", t.FrontMatter),
+		Checks:   bashEnv(t.FrontMatter.Env, "local -x") + t.suiteChecks + bashChecks(t.FrontMatter, "return 0"),
+		Undo:     t.Run.HasUndo(),
+		Run:      run,
+		Cleanup:  t.Cleanup.BashString(false, false),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot generate bash test %v", t.Name)
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+var nameRegex = regexp.MustCompile("[^a-zA-Z0-9]+")
+var spaceRegex = regexp.MustCompile(`[\t\r\n]+`)
+
+func normalizeName(s string) string {
+	return strings.ToLower(nameRegex.ReplaceAllString(s, "_"))
+}
+
+// normalizeSpaces removes empty lines and indents from the generated code.
+// The header before the package clause is kept as is, because build constraints should be followed by a blank line.
+func normalizeSpaces(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "\npackage "); i >= 0 {
+		return strings.TrimSpace(s[:i]) + "\n" + spaceRegex.ReplaceAllString(s[i+1:], "\n")
+	}
+	return spaceRegex.ReplaceAllString(s, "\n")
+}
+
+func runnerString(dir string, timeout time.Duration, retry *parser.Retry) string {
+	result := fmt.Sprintf("s.Runner(%q)", dir)
+	if timeout > 0 {
+		result += fmt.Sprintf(".WithTimeout(%v)", durationString(timeout))
+	}
+	if retry != nil {
+		interval := "0"
+		if retry.Interval > 0 {
+			interval = durationString(retry.Interval)
+		}
+		result += fmt.Sprintf(".WithRetry(%v, %v)", retry.Attempts, interval)
+	}
+	return result
+}
+
+func durationString(d time.Duration) string {
+	for _, unit := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{name: "time.Hour", value: time.Hour},
+		{name: "time.Minute", value: time.Minute},
+		{name: "time.Second", value: time.Second},
+		{name: "time.Millisecond", value: time.Millisecond},
+	} {
+		if d%unit.value == 0 {
+			return fmt.Sprintf("%d*%v", d/unit.value, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
+func secondsString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+func normalizeDeps(module string, deps []string) Dependencies {
+	var d Dependencies
+	for _, dep := range deps {
+		pieces := strings.Split(filepath.Clean(dep), string(filepath.Separator))
+		for i := 0; i < len(pieces); i++ {
+			pieces[i] = normalizeName(pieces[i])
+		}
+		pieces = append([]string{module}, pieces...)
+		d = append(d, Dependency(filepath.Join(pieces...)))
+	}
+	return d
+}
+
+// moduleName returns the import path of the start dir. Returns empty string if the dir is not in a module
+func moduleName(start string) (string, error) {
+	absDir, err := filepath.Abs(start)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot resolve dir %v", start)
+	}
+	root, module, err := findModule(absDir)
+	if err != nil || root == "" {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, absDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot resolve dir %v", start)
+	}
+	return filepath.Clean(filepath.Join(module, rel)), nil
+}
+
+// modulePath returns the path relative to the root of the module containing it.
+// Paths outside of modules are returned as absolute paths
+func modulePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot resolve path %v", path)
+	}
+	root, _, err := findModule(filepath.Dir(absPath))
+	if err != nil || root == "" {
+		return absPath, err
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot resolve path %v", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// findModule returns the root dir and the name of the module that contains the absolute dir.
+// Returns empty strings if the dir is not in a module
+func findModule(absDir string) (root, module string, err error) {
+	for currDir := absDir; ; {
+		p := filepath.Join(currDir, "go.mod")
+		source, err := os.ReadFile(filepath.Clean(p))
+		if err == nil {
+			return currDir, strings.TrimSpace(strings.TrimPrefix(strings.Split(string(source), "\n")[0], "module ")), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", errors.Wrapf(err, "cannot read %v", p)
+		}
+		parent := filepath.Dir(currDir)
+		if parent == currDir {
+			return "", "", nil
+		}
+		currDir = parent
+	}
+}
@@ -18,8 +18,9 @@ package linker
 
 import (
 	"path/filepath"
+	"strings"
 
-	"github.com/networkservicemesh/gotestmd/internal/parser"
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
 )
 
 // LinkedExample represents parser.Example with links
@@ -88,7 +89,7 @@ func NewLinkedExample(root string, e *parser.Example) *LinkedExample {
 	if len(root) >= len(e.Dir) {
 		result.Name = ""
 	} else {
-		result.Name = filepath.Clean(e.Dir[len(root):])
+		result.Name = strings.TrimPrefix(filepath.Clean(e.Dir[len(root):]), string(filepath.Separator))
 	}
 
 	for i := 0; i < len(e.Includes); i++ {
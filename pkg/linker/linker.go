@@ -20,7 +20,7 @@ package linker
 import (
 	"github.com/pkg/errors"
 
-	"github.com/networkservicemesh/gotestmd/internal/parser"
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
 )
 
 // Linker can add links between examples
@@ -18,9 +18,23 @@ package parser
 
 // Example represents a markdown example. Contains all needed for generating suites content.
 type Example struct {
-	Includes []string
-	Requires []string
-	Run      []string
-	Cleanup  []string
-	Dir      string
+	FrontMatter FrontMatter
+	Includes    []string
+	Requires    []string
+	Run         []*Step
+	Cleanup     []*Step
+	// Diagnostics contains steps that collect diagnostics when the example fails
+	Diagnostics []*Step
+	Dir         string
+	File        string
+}
+
+// RunScripts returns scripts of the run steps
+func (e *Example) RunScripts() []string {
+	return Scripts(e.Run)
+}
+
+// CleanupScripts returns scripts of the cleanup steps
+func (e *Example) CleanupScripts() []string {
+	return Scripts(e.Cleanup)
 }
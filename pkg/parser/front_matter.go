@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"go/build/constraint"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/networkservicemesh/gotestmd/pkg/tags"
+)
+
+const frontMatterDelim = "---"
+
+var envNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+var platformRegex = regexp.MustCompile(`^[a-z0-9]+(/[a-z0-9]+)?$`)
+
+// Retry is a retry policy for the commands of the example
+type Retry struct {
+	// Attempts is a max number of attempts for each command. Zero means retrying until the timeout
+	Attempts int `yaml:"attempts"`
+	// Interval is a delay between attempts
+	Interval time.Duration `yaml:"interval"`
+}
+
+// FrontMatter represents yaml metadata in the beginning of the markdown example
+type FrontMatter struct {
+	// Name overrides the name of the generated test
+	Name string `yaml:"name"`
+	// Labels are used for selecting examples
+	Labels []string `yaml:"labels"`
+	// Timeout is a timeout for each command of the example
+	Timeout time.Duration `yaml:"timeout"`
+	// Retry is a retry policy for the commands of the example
+	Retry *Retry `yaml:"retry"`
+	// Skip is a reason for skipping the example
+	Skip string `yaml:"skip"`
+	// Env contains env variables for the commands of the example. Variables set in the environment take precedence
+	Env map[string]string `yaml:"env"`
+	// RequiresEnv contains env variables that must be set for running the example
+	RequiresEnv []string `yaml:"requires-env"`
+	// Owners are responsible for the example
+	Owners []string `yaml:"owners"`
+	// Platforms contains GOOS or GOOS/GOARCH values the example can be run on
+	Platforms []string `yaml:"platforms"`
+	// EstimatedDuration is an expected duration of the example, used for CI matrices
+	EstimatedDuration time.Duration `yaml:"estimated-duration"`
+	// BuildTags is a build constraint expression for the generated suite, e.g. "integration && linux"
+	BuildTags string `yaml:"build-tags"`
+	// Parallel allows running the example in parallel with other parallel examples
+	Parallel bool `yaml:"parallel"`
+}
+
+// parseFrontMatter returns front matter of the source and the source without front matter
+func parseFrontMatter(source string) (FrontMatter, string, error) {
+	var result FrontMatter
+
+	lines := strings.SplitAfter(source, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterDelim {
+		return result, source, nil
+	}
+
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == frontMatterDelim {
+			decoder := yaml.NewDecoder(strings.NewReader(source[len(lines[0]):offset]))
+			decoder.KnownFields(true)
+			if err := decoder.Decode(&result); err != nil && !errors.Is(err, io.EOF) {
+				return result, source, errors.Wrap(err, "cannot parse front matter")
+			}
+			if err := result.validate(); err != nil {
+				return result, source, err
+			}
+			return result, source[offset+len(line):], nil
+		}
+		offset += len(line)
+	}
+
+	return result, source, errors.New("front matter is not closed")
+}
+
+func (f *FrontMatter) validate() error {
+	if f.Timeout < 0 {
+		return errors.Errorf("timeout can not be negative: %v", f.Timeout)
+	}
+	if f.EstimatedDuration < 0 {
+		return errors.Errorf("estimated-duration can not be negative: %v", f.EstimatedDuration)
+	}
+	if f.Retry != nil && (f.Retry.Attempts < 0 || f.Retry.Interval < 0) {
+		return errors.Errorf("retry attempts and interval can not be negative: %+v", *f.Retry)
+	}
+	for _, label := range f.Labels {
+		if !tags.IsValidLabel(label) {
+			return errors.Errorf("invalid label %q", label)
+		}
+	}
+	for env := range f.Env {
+		if !envNameRegex.MatchString(env) {
+			return errors.Errorf("invalid env name in env: %v", env)
+		}
+	}
+	for _, env := range f.RequiresEnv {
+		if !envNameRegex.MatchString(env) {
+			return errors.Errorf("invalid env name in requires-env: %v", env)
+		}
+	}
+	for _, platform := range f.Platforms {
+		if !platformRegex.MatchString(platform) {
+			return errors.Errorf("invalid platform %v. Expected GOOS or GOOS/GOARCH", platform)
+		}
+	}
+	if f.BuildTags != "" {
+		if _, err := constraint.Parse("//go:build " + f.BuildTags); err != nil {
+			return errors.Wrapf(err, "invalid build-tags %v", f.BuildTags)
+		}
+	}
+	return nil
+}
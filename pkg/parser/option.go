@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+// Option is an option for the Parser
+type Option func(p *Parser)
+
+// WithRunSection sets headings of the section with setup steps
+func WithRunSection(headings ...string) Option {
+	return func(p *Parser) {
+		p.run = headings
+	}
+}
+
+// WithCleanupSection sets headings of the section with cleanup steps
+func WithCleanupSection(headings ...string) Option {
+	return func(p *Parser) {
+		p.cleanup = headings
+	}
+}
+
+// WithDiagnosticsSection sets headings of the section with steps collecting diagnostics on failure
+func WithDiagnosticsSection(headings ...string) Option {
+	return func(p *Parser) {
+		p.diagnostics = headings
+	}
+}
+
+// WithIncludesSection sets headings of the section with included examples
+func WithIncludesSection(headings ...string) Option {
+	return func(p *Parser) {
+		p.includes = headings
+	}
+}
+
+// WithRequiresSection sets headings of the section with required examples
+func WithRequiresSection(headings ...string) Option {
+	return func(p *Parser) {
+		p.requires = headings
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parser provides a markdown file reader and model
+package parser
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Parser is markdown file reader
+type Parser struct {
+	linkRegex   *regexp.Regexp
+	run         []string
+	cleanup     []string
+	diagnostics []string
+	includes    []string
+	requires    []string
+}
+
+// New creates new Parser instance
+func New(options ...Option) *Parser {
+	p := &Parser{
+		linkRegex:   regexp.MustCompile(`\[.*\]\(.*\)`),
+		run:         []string{"Run"},
+		cleanup:     []string{"Cleanup"},
+		diagnostics: []string{"Diagnostics", "OnFailure"},
+		includes:    []string{"Includes"},
+		requires:    []string{"Requires"},
+	}
+	for _, o := range options {
+		o(p)
+	}
+	return p
+}
+
+// ParseFile reads file
+func (p *Parser) ParseFile(filePath string) (*Example, error) {
+	f, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	v, err := p.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	v.Dir = filepath.Dir(filePath)
+	v.File = filePath
+	for _, steps := range [][]*Step{v.Run, v.Cleanup, v.Diagnostics} {
+		for _, step := range steps {
+			step.File = filePath
+			if step.Undo != nil {
+				step.Undo.File = filePath
+			}
+		}
+	}
+	return v, nil
+}
+
+// Parse reads io.Reader
+func (p *Parser) Parse(r io.Reader) (*Example, error) {
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(bytes)
+	frontMatter, source, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// lineOf returns the line of the position in the section that starts at the offset of the source
+	lineOf := func(offset, pos int) int {
+		return strings.Count(content[:len(content)-len(source)+offset+pos], "\n") + 1
+	}
+
+	parseScript := func(s string, offset int) ([]*Step, error) {
+		const (
+			scriptBegin = "```bash"
+			scriptEnd   = "```"
+		)
+
+		var r []*Step
+		for start := strings.Index(s, scriptBegin); start >= 0; start = strings.Index(s, scriptBegin) {
+			start += len(scriptBegin)
+
+			info := ""
+			if lineEnd := strings.IndexByte(s[start:], '\n'); lineEnd >= 0 {
+				info = s[start : start+lineEnd]
+				start += lineEnd
+			}
+
+			end := strings.Index(s[start:], scriptEnd)
+			if end < 0 {
+				break
+			}
+			end += start
+
+			script := s[start:end]
+			step := &Step{
+				Script:      strings.TrimSpace(script),
+				Annotations: parseAnnotations(info),
+				Line:        lineOf(offset, start+len(script)-len(strings.TrimLeftFunc(script, unicode.IsSpace))),
+			}
+			s = s[end+len(scriptEnd):]
+			offset += end + len(scriptEnd)
+			if !step.Annotations.Bool("undo") {
+				r = append(r, step)
+				continue
+			}
+			if len(r) == 0 || r[len(r)-1].Undo != nil {
+				return nil, errors.Errorf("undo block should follow a step without undo: %v", step.Script)
+			}
+			r[len(r)-1].Undo = step
+		}
+		return r, nil
+	}
+
+	result := &Example{
+		FrontMatter: frontMatter,
+		Includes:    p.parseLinks(parseSection(p.includes, source)),
+		Requires:    p.parseLinks(parseSection(p.requires, source)),
+	}
+	for _, section := range []struct {
+		headings []string
+		steps    *[]*Step
+	}{
+		{headings: p.run, steps: &result.Run},
+		{headings: p.cleanup, steps: &result.Cleanup},
+		{headings: p.diagnostics, steps: &result.Diagnostics},
+	} {
+		start, end := findSection(section.headings, source)
+		if *section.steps, err = parseScript(source[start:end], start); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (p *Parser) parseLinks(s string) []string {
+	var result []string
+	links := p.linkRegex.FindAllString(s, -1)
+	for _, link := range links {
+		start := strings.IndexRune(link, '(') + len("(")
+		end := strings.IndexRune(link, ')')
+		result = append(result, link[start:end])
+	}
+	return result
+}
+
+// parseSection returns the content of the first section which heading matches one of the passed headings.
+// Headings are compared case-insensitively and can be of any level. The section ends at the next heading.
+func parseSection(headings []string, s string) string {
+	start, end := findSection(headings, s)
+	return s[start:end]
+}
+
+// findSection returns bounds of the section content like parseSection. Empty bounds mean that the section is not found
+func findSection(headings []string, s string) (start, end int) {
+	const blockDelim = "```"
+
+	var inBlock bool
+	start = -1
+	var offset int
+	for _, line := range strings.SplitAfter(s, "\n") {
+		lineStart := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, blockDelim) {
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock {
+			continue
+		}
+
+		title, ok := parseHeading(trimmed)
+		if !ok {
+			continue
+		}
+		if start >= 0 {
+			return start, lineStart
+		}
+		for _, heading := range headings {
+			if strings.EqualFold(title, strings.TrimSpace(heading)) {
+				start = offset
+				break
+			}
+		}
+	}
+
+	if start < 0 {
+		return 0, 0
+	}
+	return start, len(s)
+}
+
+func parseHeading(line string) (string, bool) {
+	const maxLevel = 6
+
+	title := strings.TrimLeft(line, "#")
+	if level := len(line) - len(title); level == 0 || level > maxLevel {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimRight(title, "# \t")), true
+}
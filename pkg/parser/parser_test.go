@@ -0,0 +1,192 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+const customHeadingsExample = `# Example
+
+## Prerequisites
+
+- [Producer](../Producer)
+
+## STEPS
+
+` + "```bash" + `
+# not a heading
+echo step
+` + "```" + `
+
+## Teardown
+
+` + "```bash" + `
+echo teardown
+` + "```" + `
+
+## Results
+` + "```bash" + `
+echo not a step
+` + "```" + `
+`
+
+func TestParseDefaultHeadings(t *testing.T) {
+	example, err := parser.New().Parse(strings.NewReader(customHeadingsExample))
+	require.NoError(t, err)
+	require.Empty(t, example.Run)
+	require.Empty(t, example.Cleanup)
+	require.Empty(t, example.Requires)
+}
+
+func TestParseHeadingAliases(t *testing.T) {
+	p := parser.New(
+		parser.WithRunSection("Run", "Steps"),
+		parser.WithCleanupSection("Cleanup", "teardown"),
+		parser.WithRequiresSection("Requires", "Prerequisites"),
+	)
+
+	example, err := p.Parse(strings.NewReader(customHeadingsExample))
+	require.NoError(t, err)
+	require.Len(t, example.Run, 1)
+	require.Equal(t, "# not a heading\necho step", example.Run[0].Script)
+	require.Len(t, example.Cleanup, 1)
+	require.Equal(t, "echo teardown", example.Cleanup[0].Script)
+	require.Equal(t, []string{"../Producer"}, example.Requires)
+	require.Empty(t, example.Includes)
+}
+
+func TestParseAnnotations(t *testing.T) {
+	example, err := parser.New().Parse(strings.NewReader("# Run\n```bash flaky ready=\"curl -s localhost:8080\"\necho run\n```\n"))
+	require.NoError(t, err)
+	require.Len(t, example.Run, 1)
+	require.Equal(t, "echo run", example.Run[0].Script)
+	require.True(t, example.Run[0].Annotations.Bool("flaky"))
+	require.False(t, example.Run[0].Annotations.Has("background"))
+	require.Equal(t, "curl -s localhost:8080", example.Run[0].Annotations.Get("ready"))
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	example, err := parser.New().Parse(strings.NewReader("---\nbuild-tags: integration && !windows\n---\n# Run\n```bash\necho run\n```\n"))
+	require.NoError(t, err)
+	require.Equal(t, "integration && !windows", example.FrontMatter.BuildTags)
+	require.Len(t, example.Run, 1)
+
+	_, err = parser.New().Parse(strings.NewReader("---\nbuild-tags: integration &&\n---\n"))
+	require.Error(t, err)
+
+	_, err = parser.New().Parse(strings.NewReader("---\nunknown: field\n---\n"))
+	require.Error(t, err)
+}
+
+func TestParseFrontMatterMetadata(t *testing.T) {
+	source := `---
+name: Smoke
+labels: [smoke, fast]
+timeout: 2m
+retry:
+  attempts: 3
+  interval: 500ms
+skip: flaky on CI
+env:
+  NAMESPACE: ns-smoke
+requires-env: [KUBECONFIG]
+owners: [alice, bob]
+platforms: [linux, darwin/arm64]
+---
+# Run
+` + "```bash\necho run\n```\n"
+
+	example, err := parser.New().Parse(strings.NewReader(source))
+	require.NoError(t, err)
+	require.Equal(t, parser.FrontMatter{
+		Name:        "Smoke",
+		Labels:      []string{"smoke", "fast"},
+		Timeout:     2 * time.Minute,
+		Retry:       &parser.Retry{Attempts: 3, Interval: 500 * time.Millisecond},
+		Skip:        "flaky on CI",
+		Env:         map[string]string{"NAMESPACE": "ns-smoke"},
+		RequiresEnv: []string{"KUBECONFIG"},
+		Owners:      []string{"alice", "bob"},
+		Platforms:   []string{"linux", "darwin/arm64"},
+	}, example.FrontMatter)
+
+	for _, invalid := range []string{
+		"timeout: -1s",
+		"retry: {attempts: -1}",
+		"requires-env: [NOT-VALID]",
+		"env: {NOT-VALID: value}",
+		"platforms: [linux/amd64/v2]",
+	} {
+		_, err = parser.New().Parse(strings.NewReader("---\n" + invalid + "\n---\n"))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	for _, heading := range []string{"Diagnostics", "OnFailure"} {
+		example, err := parser.New().Parse(strings.NewReader("# Run\n```bash\necho run\n```\n## " + heading + "\n```bash\nkubectl get pods\n```\n"))
+		require.NoError(t, err)
+		require.Len(t, example.Diagnostics, 1, heading)
+		require.Equal(t, "kubectl get pods", example.Diagnostics[0].Script)
+		require.Len(t, example.Run, 1)
+	}
+}
+
+func TestParseUndo(t *testing.T) {
+	example, err := parser.New().Parse(strings.NewReader("# Run\n```bash\necho create\n```\n```bash undo\necho delete\n```\n```bash\necho check\n```\n"))
+	require.NoError(t, err)
+	require.Len(t, example.Run, 2)
+	require.Equal(t, "echo create", example.Run[0].Script)
+	require.Equal(t, "echo delete", example.Run[0].Undo.Script)
+	require.Nil(t, example.Run[1].Undo)
+	// undo steps are not run steps
+	require.Equal(t, []string{"echo create", "echo check"}, example.RunScripts())
+	require.Empty(t, example.CleanupScripts())
+
+	for _, invalid := range []string{
+		"# Run\n```bash undo\necho delete\n```\n",
+		"# Run\n```bash\necho create\n```\n```bash undo\necho delete\n```\n```bash undo\necho delete\n```\n",
+	} {
+		_, err = parser.New().Parse(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestParseStepLocations(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "README.md")
+	require.NoError(t, os.WriteFile(file, []byte("---\ntimeout: 1m\n---\n# Run\n```bash\necho same\n```\n```bash undo\n\necho undo\n```\n# Cleanup\n```bash\necho same\n```\n"), 0o600))
+
+	example, err := parser.New().ParseFile(file)
+	require.NoError(t, err)
+	require.Equal(t, filepath.ToSlash(file)+":6", example.Run[0].Location())
+	require.Equal(t, filepath.ToSlash(file)+":10", example.Run[0].Undo.Location())
+	require.Equal(t, filepath.ToSlash(file)+":14", example.Cleanup[0].Location())
+
+	example, err = parser.New().Parse(strings.NewReader("# Run\n```bash\necho run\n```\n"))
+	require.NoError(t, err)
+	require.Equal(t, 3, example.Run[0].Line)
+	require.Empty(t, example.Run[0].Location())
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Annotations contains key=value pairs from the info string of the code block, e.g. ```bash key=value flag
+type Annotations map[string]string
+
+// Has returns true if the annotation is present
+func (a Annotations) Has(key string) bool {
+	_, ok := a[key]
+	return ok
+}
+
+// Get returns the value of the annotation
+func (a Annotations) Get(key string) string {
+	return a[key]
+}
+
+// Bool returns true if the annotation is present and its value is not "false"
+func (a Annotations) Bool(key string) bool {
+	v, ok := a[key]
+	return ok && v != "false"
+}
+
+// Step represents a bash code block of the markdown example
+type Step struct {
+	Script      string
+	Annotations Annotations
+	// Undo reverts the step. It is a code block with the undo annotation that follows the step
+	Undo *Step
+	// File is the markdown file of the step. It is empty if the step is not read from a file
+	File string
+	// Line is the line of the script in the markdown source. Zero means the line is unknown
+	Line int
+}
+
+// NewSteps creates steps without annotations from the scripts
+func NewSteps(scripts ...string) []*Step {
+	var result []*Step
+	for _, script := range scripts {
+		result = append(result, &Step{Script: script})
+	}
+	return result
+}
+
+// Location returns the file and the line of the step, e.g. examples/helloworld/README.md:10, or empty string if they are unknown
+func (s *Step) Location() string {
+	if s.File == "" || s.Line == 0 {
+		return ""
+	}
+	return filepath.ToSlash(s.File) + ":" + strconv.Itoa(s.Line)
+}
+
+// Scripts returns scripts of the steps
+func Scripts(steps []*Step) []string {
+	var result []string
+	for _, step := range steps {
+		result = append(result, step.Script)
+	}
+	return result
+}
+
+// String returns the script of the step
+func (s *Step) String() string {
+	return s.Script
+}
+
+func parseAnnotations(info string) Annotations {
+	var result = Annotations{}
+	for _, field := range splitFields(info) {
+		key, value, _ := strings.Cut(field, "=")
+		if key == "" {
+			continue
+		}
+		result[key] = strings.Trim(value, `"`)
+	}
+	return result
+}
+
+func splitFields(s string) []string {
+	var result []string
+	var quoted bool
+	var field strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			field.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if field.Len() > 0 {
+				result = append(result, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 {
+		result = append(result, field.String())
+	}
+	return result
+}
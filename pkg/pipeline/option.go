@@ -0,0 +1,39 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"github.com/networkservicemesh/gotestmd/pkg/linker"
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+)
+
+// Option is an option for the Pipeline
+type Option func(p *Pipeline)
+
+// WithParser sets a custom parser for the markdown files
+func WithParser(parser *parser.Parser) Option {
+	return func(p *Pipeline) {
+		p.parser = parser
+	}
+}
+
+// WithLinker sets a custom linker for the parsed examples
+func WithLinker(linker *linker.Linker) Option {
+	return func(p *Pipeline) {
+		p.linker = linker
+	}
+}
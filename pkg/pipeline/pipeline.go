@@ -0,0 +1,384 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipeline provides the parse, link and generate steps of gotestmd
+package pipeline
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+	"github.com/networkservicemesh/gotestmd/pkg/generator"
+	"github.com/networkservicemesh/gotestmd/pkg/linker"
+	"github.com/networkservicemesh/gotestmd/pkg/parser"
+	"github.com/networkservicemesh/gotestmd/pkg/tags"
+)
+
+// Pipeline parses markdown examples, links them and generates suites for the configured targets
+type Pipeline struct {
+	conf   config.Config
+	parser *parser.Parser
+	linker *linker.Linker
+}
+
+// New creates new Pipeline instance. Use config.Config.Validate to check the config before running the pipeline
+func New(conf config.Config, options ...Option) *Pipeline {
+	p := &Pipeline{
+		conf: conf,
+		parser: parser.New(
+			parser.WithRunSection(conf.Sections.Run...),
+			parser.WithCleanupSection(conf.Sections.Cleanup...),
+			parser.WithDiagnosticsSection(conf.Sections.Diagnostics...),
+			parser.WithIncludesSection(conf.Sections.Includes...),
+			parser.WithRequiresSection(conf.Sections.Requires...),
+		),
+		linker: linker.New(conf.InputDir),
+	}
+	for _, o := range options {
+		o(p)
+	}
+	return p
+}
+
+// Config returns the config of the pipeline
+func (p *Pipeline) Config() config.Config {
+	return p.conf
+}
+
+// Run parses, links, generates and saves suites for all configured targets
+func (p *Pipeline) Run() error {
+	examples, err := p.Parse()
+	if err != nil {
+		return err
+	}
+	linkedExamples, err := p.Link(examples...)
+	if err != nil {
+		return err
+	}
+	for _, target := range p.conf.Targets {
+		suites, err := p.Generate(target, linkedExamples...)
+		if err != nil {
+			return err
+		}
+		if err := p.Write(target, suites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse reads all examples from the input dir
+func (p *Pipeline) Parse() ([]*parser.Example, error) {
+	var examples []*parser.Example
+	for _, dir := range getRecursiveDirectories(p.conf.InputDir, p.conf.Ignore) {
+		file := findExampleFile(dir, p.conf.Patterns)
+		if file == "" {
+			continue
+		}
+		ex, err := p.parser.ParseFile(file)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse example %v: %v", file, err.Error())
+		}
+		examples = append(examples, ex)
+	}
+	return examples, nil
+}
+
+// Link adds links between the examples
+func (p *Pipeline) Link(examples ...*parser.Example) ([]*linker.LinkedExample, error) {
+	linkedExamples, err := p.linker.Link(examples...)
+	if err != nil {
+		return nil, errors.Errorf("cannot build examples: %v", err.Error())
+	}
+	return linkedExamples, nil
+}
+
+// Generate generates suites for the target
+func (p *Pipeline) Generate(target string, examples ...*linker.LinkedExample) ([]*generator.Suite, error) {
+	g, err := p.generator(target)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := g.Generate(examples...)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.conf.Tags != "" || p.conf.ExcludeTags != "" {
+		selector, err := tags.NewSelector(p.conf.Tags, p.conf.ExcludeTags)
+		if err != nil {
+			return nil, err
+		}
+		// labels of included tests and suites can be excluded, so a suite is accepted as a whole only if it has nothing to filter
+		suites = generator.Select(suites, func(s *generator.Suite, t *generator.Test) bool {
+			if t != nil {
+				return selector.Match(t.Labels)
+			}
+			return selector.Match(s.Labels) && len(s.Tests)+len(s.Children) == 0
+		})
+		if len(suites) == 0 {
+			return nil, errors.Errorf("No examples found for tags %q and exclude tags %q", p.conf.Tags, p.conf.ExcludeTags)
+		}
+	}
+	// bash scripts are matched when they are written
+	if target != config.TargetBash && p.conf.Match != "" {
+		matchRegex, err := regexp.Compile(p.conf.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid match %v", p.conf.Match)
+		}
+		suites = generator.Select(suites, func(s *generator.Suite, t *generator.Test) bool {
+			if t != nil {
+				return matchRegex.MatchString(t.Name)
+			}
+			return matchRegex.MatchString(s.Name())
+		})
+		if len(suites) == 0 {
+			return nil, errors.Errorf("No matches found for pattern: %s", matchRegex.String())
+		}
+	}
+	return suites, nil
+}
+
+// Matrix generates a CI matrix with an entry for each top-level suite
+func (p *Pipeline) Matrix() (*generator.Matrix, error) {
+	examples, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	linkedExamples, err := p.Link(examples...)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := p.Generate(config.TargetGo, linkedExamples...)
+	if err != nil {
+		return nil, err
+	}
+	g, err := p.generator(config.TargetGo)
+	if err != nil {
+		return nil, err
+	}
+	return g.GenerateMatrix(suites, p.conf.Roots...), nil
+}
+
+// Write saves suites generated for the target into the output dir
+func (p *Pipeline) Write(target string, suites []*generator.Suite) error {
+	_ = os.MkdirAll(p.conf.OutputDir, os.ModePerm)
+
+	if target == config.TargetBash {
+		var matchRegex *regexp.Regexp
+		if p.conf.Match != "" {
+			var err error
+			if matchRegex, err = regexp.Compile(p.conf.Match); err != nil {
+				return errors.Wrapf(err, "invalid match %v", p.conf.Match)
+			}
+		}
+		return processBashSuites(suites, matchRegex, p.conf.Retry.Enabled)
+	}
+
+	if target == config.TargetMake {
+		g, err := p.generator(target)
+		if err != nil {
+			return err
+		}
+		return processMakefile(g.GenerateMakefile(suites))
+	}
+
+	if err := processGoSuites(suites); err != nil || !p.conf.EntryPoint {
+		return err
+	}
+	g, err := p.generator(target)
+	if err != nil {
+		return err
+	}
+	return processEntryPoint(g.GenerateEntryPoint(suites, p.conf.Roots...))
+}
+
+func (p *Pipeline) generator(target string) (*generator.Generator, error) {
+	templates, err := generator.LoadTemplates(p.conf.Templates)
+	if err != nil {
+		return nil, err
+	}
+	return generator.New(p.conf, generator.WithTarget(target), generator.WithTemplates(templates)), nil
+}
+
+func processGoSuites(suites []*generator.Suite) error {
+	for _, suite := range suites {
+		dir, _ := filepath.Split(suite.Location)
+		_ = os.MkdirAll(dir, os.ModePerm)
+		content, err := suite.Render()
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(suite.Location, []byte(content), os.ModePerm)
+		if err != nil {
+			return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
+		}
+		files, err := suite.RenderBuildTagFiles()
+		if err != nil {
+			return err
+		}
+		for location, content := range files {
+			if err := os.WriteFile(location, []byte(content), os.ModePerm); err != nil {
+				return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func processEntryPoint(entryPoint *generator.EntryPoint) error {
+	if len(entryPoint.Suites) == 0 {
+		return errors.New("No top-level suites found for the entry point")
+	}
+	err := os.WriteFile(entryPoint.Location, []byte(entryPoint.String()), os.ModePerm)
+	if err != nil {
+		return errors.Errorf("cannot save entry point %v, : %v", entryPoint.Location, err.Error())
+	}
+
+	return nil
+}
+
+func processMakefile(makefile *generator.Makefile) error {
+	content, err := makefile.Render()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(makefile.Location, []byte(content), os.ModePerm); err != nil {
+		return errors.Errorf("cannot save makefile %v, : %v", makefile.Location, err.Error())
+	}
+	return nil
+}
+
+func processBashSuites(suites []*generator.Suite, matchRegex *regexp.Regexp, retry bool) error {
+	if matchRegex == nil {
+		for _, suite := range suites {
+			if err := writeBashSuite(suite, retry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	matchFound := false
+
+	for _, suite := range suites {
+		if !matchRegex.MatchString(suite.Name()) {
+			continue
+		}
+		matchFound = true
+		suite.Tests = nil
+		if err := writeBashSuite(suite, retry); err != nil {
+			return err
+		}
+	}
+
+	for _, suite := range suites {
+		matchedTests := make([]*generator.Test, 0)
+		for _, test := range suite.Tests {
+			if matchRegex.MatchString(test.Name) {
+				matchedTests = append(matchedTests, test)
+				matchFound = true
+			}
+		}
+		if len(matchedTests) == 0 {
+			continue
+		}
+
+		suite.Tests = matchedTests
+		if err := writeBashSuite(suite, retry); err != nil {
+			return err
+		}
+	}
+
+	if !matchFound {
+		return errors.Errorf("No matches found for pattern: %s", matchRegex.String())
+	}
+
+	return nil
+}
+
+func writeBashSuite(suite *generator.Suite, retry bool) error {
+	dir, _ := filepath.Split(suite.Location)
+	_ = os.MkdirAll(dir, os.ModePerm)
+	content, err := suite.RenderBash(retry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(suite.Location, []byte(content), os.ModePerm); err != nil {
+		return errors.Errorf("cannot save suite %v, : %v", suite.Name(), err.Error())
+	}
+	return nil
+}
+
+func findExampleFile(dir string, patterns []string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, pattern := range patterns {
+		for _, entry := range entries {
+			if matched, _ := filepath.Match(pattern, entry.Name()); matched && !entry.IsDir() {
+				return filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return ""
+}
+
+func getFilter(root string, ignore []string) func(string) bool {
+	return func(s string) bool {
+		rel, err := filepath.Rel(root, s)
+		if err != nil {
+			return false
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range ignore {
+			pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+			if matched, _ := path.Match(pattern, rel); matched || strings.HasPrefix(rel, pattern+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func getRecursiveDirectories(root string, ignore []string) []string {
+	var result []string
+	var isIgnored = getFilter(root, ignore)
+	_ = filepath.Walk(root,
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if isIgnored(path) {
+				return filepath.SkipDir
+			}
+			result = append(result, path)
+			return nil
+		})
+
+	return result
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/config"
+	"github.com/networkservicemesh/gotestmd/pkg/pipeline"
+)
+
+func TestPipelineSteps(t *testing.T) {
+	conf, err := config.FromArgs([]string{"../../examples", t.TempDir()})
+	require.NoError(t, err)
+
+	p := pipeline.New(conf)
+
+	examples, err := p.Parse()
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+
+	linkedExamples, err := p.Link(examples...)
+	require.NoError(t, err)
+	require.Len(t, linkedExamples, len(examples))
+
+	suites, err := p.Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range suites {
+		names = append(names, s.Path)
+	}
+	require.Contains(t, names, "tree")
+	require.Contains(t, names, "producer/consumer2")
+	require.NotContains(t, names, "producer/consumer1")
+}
+
+func TestPipelineRun(t *testing.T) {
+	outputDir := t.TempDir()
+	conf, err := config.FromArgs([]string{"../../examples", outputDir})
+	require.NoError(t, err)
+	conf.EntryPoint = true
+
+	require.NoError(t, pipeline.New(conf).Run())
+
+	_, err = os.Stat(filepath.Join(outputDir, "tree", "suite.gen.go"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(outputDir, "entry_point_test.go"))
+	require.NoError(t, err)
+}
+
+func TestPipelineCustomTemplates(t *testing.T) {
+	outputDir := t.TempDir()
+	templatePath := filepath.Join(t.TempDir(), "suite.tmpl")
+	require.NoError(t, os.WriteFile(templatePath, []byte(`package {{ .Name }}
+{{ range .Suite.Run }}
+// step: {{ .Script }}
+{{- end }}
+`), os.ModePerm))
+
+	conf, err := config.FromArgs([]string{"../../examples", outputDir})
+	require.NoError(t, err)
+	conf.Templates.Suite = templatePath
+
+	require.NoError(t, pipeline.New(conf).Run())
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "helloworld", "suite.gen.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package helloworld\n// step: # Hello world!\necho \"Hello world!\"", string(content))
+
+	conf.Templates.Suite = filepath.Join(t.TempDir(), "missing.tmpl")
+	require.Error(t, pipeline.New(conf).Run())
+}
+
+func TestPipelineFrontMatter(t *testing.T) {
+	inputDir, outputDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(inputDir, "suite", "test"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "suite", "README.md"), []byte("---\n"+
+		"timeout: 2m\nowners: [alice]\nplatforms: [linux]\nrequires-env: [KUBECONFIG]\n---\n"+
+		"# Includes\n- [Test](./test)\n# Run\n```bash\necho setup\n```\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "suite", "test", "README.md"), []byte("---\n"+
+		"name: smoke check\nskip: not ready\nretry: {attempts: 3, interval: 500ms}\nlabels: [smoke]\n---\n"+
+		"# Run\n```bash\necho test\n```\n"), os.ModePerm))
+
+	conf, err := config.FromArgs([]string{inputDir, outputDir})
+	require.NoError(t, err)
+	conf.Retry.Enabled = true
+
+	p := pipeline.New(conf)
+	examples, err := p.Parse()
+	require.NoError(t, err)
+	linkedExamples, err := p.Link(examples...)
+	require.NoError(t, err)
+
+	suites, err := p.Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+
+	content, err := suites[0].Render()
+	require.NoError(t, err)
+	require.Contains(t, content, "//\n// Owners: alice\npackage suite")
+	require.Contains(t, content, "s.SkipUnlessPlatform(\"linux\")\ns.RequireEnv(\"KUBECONFIG\")")
+	require.Contains(t, content, ".WithTimeout(2*time.Minute)")
+	require.Contains(t, content, "// Labels: smoke\nfunc (s *Suite) TestSmoke_check() {\ns.SkipUnlessLabels([]string{\"smoke\"})\ns.T().Skip(\"not ready\")")
+	require.Contains(t, content, ".WithRetry(3, 500*time.Millisecond)")
+
+	suites, err = p.Generate(config.TargetBash, linkedExamples...)
+	require.NoError(t, err)
+
+	content, err = suites[0].RenderBash(true)
+	require.NoError(t, err)
+	require.Contains(t, content, "#!/usr/bin/env bash\n# Owners: alice\n")
+	require.Contains(t, content, "\tlinux/*) ;;\n")
+	require.Contains(t, content, "\t[ -n \"${KUBECONFIG+x}\" ] || ")
+	require.Contains(t, content, "\tlocal RETRY_TIMEOUT_SECONDS=\"${RETRY_TIMEOUT_SECONDS:-120}\"\n")
+	require.Contains(t, content, "testSmoke_check() {\n")
+	require.Contains(t, content, "\techo 'skip: not ready'\n\treturn 0\n")
+	require.Contains(t, content, "RETRY_INTERVAL=\"${RETRY_INTERVAL:-0.5}\" RETRY_ATTEMPTS=\"${RETRY_ATTEMPTS:-3}\"")
+}
+
+func writeExamples(t *testing.T, examples map[string]string) string {
+	inputDir := t.TempDir()
+	for dir, content := range examples {
+		require.NoError(t, os.MkdirAll(filepath.Join(inputDir, dir), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(inputDir, dir, "README.md"), []byte(content), os.ModePerm))
+	}
+	return inputDir
+}
+
+func TestPipelineTags(t *testing.T) {
+	inputDir := writeExamples(t, map[string]string{
+		"base":        "---\nlabels: [base]\n---\n# Run\n```bash\necho base\n```\n",
+		"calico":      "---\nlabels: [calico]\n---\n# Requires\n- [Base](../base)\n# Includes\n- [IPv4](./ipv4)\n- [IPv6](./ipv6)\n# Run\n```bash\necho calico\n```\n",
+		"calico/ipv4": "# Run\n```bash\necho ipv4\n```\n",
+		"calico/ipv6": "---\nlabels: [ipv6]\n---\n# Run\n```bash\necho ipv6\n```\n",
+		"heal":        "---\nlabels: [heal]\n---\n# Run\n```bash\necho heal\n```\n",
+	})
+
+	conf, err := config.FromArgs([]string{inputDir, t.TempDir()})
+	require.NoError(t, err)
+	conf.Tags = "calico && !ipv6"
+
+	p := pipeline.New(conf)
+	examples, err := p.Parse()
+	require.NoError(t, err)
+	linkedExamples, err := p.Link(examples...)
+	require.NoError(t, err)
+
+	suites, err := p.Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range suites {
+		names = append(names, s.Path)
+		if s.Path == "calico" {
+			require.Len(t, s.Tests, 1)
+			require.Equal(t, "Ipv4", s.Tests[0].Name)
+			require.Equal(t, []string{"calico"}, s.Tests[0].Labels)
+
+			content, err := s.Render()
+			require.NoError(t, err)
+			require.Contains(t, content, `s.SkipUnlessLabels([]string{"calico"})`)
+		}
+	}
+	require.ElementsMatch(t, []string{"base", "calico"}, names)
+
+	conf.Tags, conf.ExcludeTags = "", "calico || base"
+	_, err = pipeline.New(conf).Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+
+	conf.Tags, conf.ExcludeTags = "unknown", ""
+	_, err = pipeline.New(conf).Generate(config.TargetGo, linkedExamples...)
+	require.Error(t, err)
+}
+
+func TestPipelineMatch(t *testing.T) {
+	conf, err := config.FromArgs([]string{"../../examples", t.TempDir()})
+	require.NoError(t, err)
+	conf.Match = "consumer2"
+
+	p := pipeline.New(conf)
+	examples, err := p.Parse()
+	require.NoError(t, err)
+	linkedExamples, err := p.Link(examples...)
+	require.NoError(t, err)
+
+	suites, err := p.Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range suites {
+		names = append(names, s.Path)
+	}
+	require.ElementsMatch(t, []string{"producer", "producer/consumer2"}, names)
+
+	// a suite matched by name keeps all its tests and included suites
+	conf.Match = "tree"
+	suites, err = pipeline.New(conf).Generate(config.TargetGo, linkedExamples...)
+	require.NoError(t, err)
+	var tests []string
+	for _, s := range suites {
+		for _, test := range s.Tests {
+			tests = append(tests, s.Path+"/"+test.Name)
+		}
+	}
+	require.ElementsMatch(t, []string{"tree/LeafA", "tree/LeafC", "tree/subtree/LeafB"}, tests)
+
+	conf.Match = "^nothing$"
+	_, err = pipeline.New(conf).Generate(config.TargetGo, linkedExamples...)
+	require.Error(t, err)
+
+	// invalid patterns are reported without validating the config
+	conf.Match = "("
+	_, err = pipeline.New(conf).Generate(config.TargetGo, linkedExamples...)
+	require.Error(t, err)
+	require.Error(t, pipeline.New(conf).Write(config.TargetBash, suites))
+}
+
+func TestPipelineMatrix(t *testing.T) {
+	inputDir := writeExamples(t, map[string]string{
+		"base":        "---\nlabels: [base]\nestimated-duration: 1m\n---\n# Run\n```bash\necho base\n```\n",
+		"calico":      "---\nlabels: [calico]\nestimated-duration: 2m\n---\n# Requires\n- [Base](../base)\n# Includes\n- [IPv6](./ipv6)\n# Run\n```bash\necho calico\n```\n",
+		"calico/ipv6": "---\nlabels: [ipv6]\nestimated-duration: 30s\n---\n# Run\n```bash\necho ipv6\n```\n",
+	})
+
+	conf, err := config.FromArgs([]string{inputDir, t.TempDir()})
+	require.NoError(t, err)
+	conf.Roots = []string{"calico"}
+
+	matrix, err := pipeline.New(conf).Matrix()
+	require.NoError(t, err)
+	require.Len(t, matrix.Include, 1)
+	require.Equal(t, "calico", matrix.Include[0].Suite)
+	require.Equal(t, []string{"calico", "ipv6"}, matrix.Include[0].Tags)
+	require.Equal(t, "3m30s", matrix.Include[0].EstimatedDuration)
+
+	// required suites are set up by the suites requiring them, so they are not roots by default
+	conf.Roots = nil
+	matrix, err = pipeline.New(conf).Matrix()
+	require.NoError(t, err)
+	require.Len(t, matrix.Include, 1)
+	require.Equal(t, "calico", matrix.Include[0].Suite)
+}
+
+func TestPipelineBashSuitesRenderedInOrder(t *testing.T) {
+	outputDir := t.TempDir()
+	conf, err := config.FromArgs([]string{"../../examples", outputDir})
+	require.NoError(t, err)
+	conf.Targets = []string{config.TargetBash}
+	conf.Match = "producer|consumer3"
+
+	// the producer is rendered before the suites requiring it
+	require.NoError(t, pipeline.New(conf).Run())
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "producer", "consumer3", "suite.gen.sh"))
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(content), "echo 'setup suite "+filepath.Join(outputDir, "producer")+"'\n"), string(content))
+	require.Equal(t, 1, strings.Count(string(content), "echo 'cleanup suite "+filepath.Join(outputDir, "producer")+"'\n"), string(content))
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Cases     []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnit writes the JUnit XML report. Each suite is a testsuite and each step is a testcase
+func (r *Reporter) WriteJUnit(w io.Writer) error {
+	var result = new(junitTestSuites)
+	var suites = map[string]*junitTestSuite{}
+	var durations = map[*junitTestSuite]time.Duration{}
+	var total time.Duration
+	for _, step := range r.Steps() {
+		suite, ok := suites[step.Suite]
+		if !ok {
+			suite = &junitTestSuite{Name: step.Suite, Timestamp: step.Start.Format("2006-01-02T15:04:05")}
+			suites[step.Suite] = suite
+			result.Suites = append(result.Suites, suite)
+		}
+		testCase := &junitTestCase{
+			Name:      stepName(step),
+			Classname: step.Test,
+			File:      step.Location,
+			Time:      fmt.Sprintf("%.3f", step.Duration.Seconds()),
+			SystemOut: step.Stdout,
+			SystemErr: step.Stderr,
+		}
+		if step.Failed() {
+			testCase.Failure = &junitFailure{
+				Message:  fmt.Sprintf("exit code %v after %v attempts at %v", step.ExitCode, step.Attempts, step.Location),
+				Contents: step.Command,
+			}
+			suite.Failures++
+			result.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		result.Tests++
+		durations[suite] += step.Duration
+		total += step.Duration
+	}
+	for _, suite := range result.Suites {
+		suite.Time = fmt.Sprintf("%.3f", durations[suite].Seconds())
+	}
+	result.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "cannot write junit report")
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return errors.Wrap(encoder.Encode(result), "cannot write junit report")
+}
+
+func stepName(step *Step) string {
+	name := firstLine(step.Command)
+	if step.Location != "" {
+		name = step.Location + " " + name
+	}
+	return name
+}
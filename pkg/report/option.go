@@ -0,0 +1,34 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+// Option is an option for the Reporter
+type Option func(r *Reporter)
+
+// WithJUnitFile sets a path of the JUnit XML report
+func WithJUnitFile(path string) Option {
+	return func(r *Reporter) {
+		r.junitFile = path
+	}
+}
+
+// WithJSONFile sets a path of the JSON report
+func WithJSONFile(path string) Option {
+	return func(r *Reporter) {
+		r.jsonFile = path
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report records executed steps of the examples and writes JUnit XML and JSON reports
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Step is an executed command of the example
+type Step struct {
+	// Suite is a name of the testify suite
+	Suite string `json:"suite"`
+	// Test is a full name of the test that executed the step
+	Test string `json:"test"`
+	// Location is a markdown file and line of the command, e.g. examples/HelloWorld/README.md:12
+	Location string        `json:"location,omitempty"`
+	Command  string        `json:"command"`
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit-code"`
+	Attempts int           `json:"attempts"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// History contains each attempt of the step
+	History []*Attempt `json:"history,omitempty"`
+}
+
+// Attempt is a single execution of the step command. Outputs are recorded only for failed attempts
+type Attempt struct {
+	ExitCode int           `json:"exit-code"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Retried returns true if the step needed more than one attempt
+func (s *Step) Retried() bool {
+	return s.Attempts > 1
+}
+
+// Failed returns true if the step didn't succeed
+func (s *Step) Failed() bool {
+	return s.ExitCode != 0
+}
+
+// Report is a JSON report
+type Report struct {
+	Steps []*Step `json:"steps"`
+}
+
+// Reporter collects steps and writes reports into the configured files
+type Reporter struct {
+	mu        sync.Mutex
+	steps     []*Step
+	junitFile string
+	jsonFile  string
+	// flushMu prevents parallel tests from writing the same files at once
+	flushMu sync.Mutex
+}
+
+// New creates new Reporter instance
+func New(options ...Option) *Reporter {
+	r := &Reporter{}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// Add records the step
+func (r *Reporter) Add(step *Step) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+// Steps returns recorded steps
+func (r *Reporter) Steps() []*Step {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Step(nil), r.steps...)
+}
+
+// WriteJSON writes the JSON report
+func (r *Reporter) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(&Report{Steps: r.Steps()}), "cannot write json report")
+}
+
+// Flush writes reports into the configured files
+func (r *Reporter) Flush() error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	for _, f := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{path: r.junitFile, write: r.WriteJUnit},
+		{path: r.jsonFile, write: r.WriteJSON},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if err := writeFile(f.path, f.write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "cannot create dir for report %v", path)
+	}
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return errors.Wrapf(err, "cannot create report %v", path)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return errors.Wrapf(f.Close(), "cannot save report %v", path)
+}
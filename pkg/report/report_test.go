@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+func newReporter(options ...report.Option) *report.Reporter {
+	r := report.New(options...)
+	r.Add(&report.Step{
+		Suite:    "TestEntryPoint/helloworld",
+		Test:     "TestEntryPoint/helloworld",
+		Location: "examples/HelloWorld/README.md:10",
+		Command:  "# Hello world!\necho \"Hello world!\"",
+		Stdout:   "Hello world!",
+		Attempts: 1,
+		Duration: time.Second,
+	})
+	r.Add(&report.Step{
+		Suite:    "TestEntryPoint/tree",
+		Test:     "TestEntryPoint/tree/TestLeafA",
+		Location: "examples/Tree/LeafA/README.md:8",
+		Command:  "false",
+		ExitCode: 1,
+		Attempts: 3,
+		Duration: 2 * time.Second,
+	})
+	return r
+}
+
+func TestReportJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, newReporter().WriteJSON(&buffer))
+
+	var result report.Report
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+	require.Len(t, result.Steps, 2)
+	require.Equal(t, "examples/HelloWorld/README.md:10", result.Steps[0].Location)
+	require.False(t, result.Steps[0].Failed())
+	require.True(t, result.Steps[1].Failed())
+	require.Equal(t, 3, result.Steps[1].Attempts)
+	require.Contains(t, buffer.String(), `"exit-code": 1`)
+}
+
+func TestReportJUnit(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, newReporter().WriteJUnit(&buffer))
+
+	xml := buffer.String()
+	require.Contains(t, xml, `<testsuites tests="2" failures="1" time="3.000">`)
+	require.Contains(t, xml, `<testsuite name="TestEntryPoint/tree" tests="1" failures="1" time="2.000"`)
+	require.Contains(t, xml, `<testcase name="examples/HelloWorld/README.md:10 # Hello world!" classname="TestEntryPoint/helloworld" file="examples/HelloWorld/README.md:10" time="1.000">`)
+	require.Contains(t, xml, `<failure message="exit code 1 after 3 attempts at examples/Tree/LeafA/README.md:8">false</failure>`)
+}
+
+func TestReportFlush(t *testing.T) {
+	dir := t.TempDir()
+	junitFile := filepath.Join(dir, "reports", "junit.xml")
+	jsonFile := filepath.Join(dir, "reports", "report.json")
+
+	require.NoError(t, newReporter(report.WithJUnitFile(junitFile), report.WithJSONFile(jsonFile)).Flush())
+	for _, file := range []string{junitFile, jsonFile} {
+		content, err := os.ReadFile(filepath.Clean(file))
+		require.NoError(t, err)
+		require.Contains(t, string(content), "examples/Tree/LeafA/README.md:8")
+	}
+}
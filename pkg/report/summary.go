@@ -0,0 +1,178 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StepSummary aggregates executions of the same step from several reports
+type StepSummary struct {
+	Example  string
+	Location string
+	Command  string
+	Runs     int
+	Failures int
+	// Retried is a number of runs that needed more than one attempt
+	Retried  int
+	Attempts int
+	Total    time.Duration
+	Max      time.Duration
+}
+
+// Mean returns the mean duration of the step
+func (s *StepSummary) Mean() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Runs)
+}
+
+// Flaky returns true if the step failed or needed retries
+func (s *StepSummary) Flaky() bool {
+	return s.Failures > 0 || s.Retried > 0
+}
+
+// ExampleSummary contains summaries of the steps of the example
+type ExampleSummary struct {
+	Example string
+	Total   time.Duration
+	Steps   []*StepSummary
+}
+
+// ReadJSON reads the JSON report
+func ReadJSON(r io.Reader) (*Report, error) {
+	var result Report
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "cannot read json report")
+	}
+	return &result, nil
+}
+
+// Aggregate groups steps by markdown locations and examples. Examples and steps are sorted by the total duration
+func Aggregate(steps ...*Step) []*ExampleSummary {
+	var result []*ExampleSummary
+	var examples = map[string]*ExampleSummary{}
+	var summaries = map[string]*StepSummary{}
+	for _, step := range steps {
+		key := step.Location
+		if key == "" {
+			key = step.Suite + "\n" + step.Command
+		}
+		summary, ok := summaries[key]
+		if !ok {
+			summary = &StepSummary{Example: exampleName(step), Location: step.Location, Command: step.Command}
+			summaries[key] = summary
+			example, ok := examples[summary.Example]
+			if !ok {
+				example = &ExampleSummary{Example: summary.Example}
+				examples[summary.Example] = example
+				result = append(result, example)
+			}
+			example.Steps = append(example.Steps, summary)
+		}
+		summary.Runs++
+		summary.Attempts += step.Attempts
+		if step.Failed() {
+			summary.Failures++
+		}
+		if step.Attempts > 1 {
+			summary.Retried++
+		}
+		summary.Total += step.Duration
+		if step.Duration > summary.Max {
+			summary.Max = step.Duration
+		}
+		examples[summary.Example].Total += step.Duration
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Total > result[j].Total
+	})
+	for _, example := range result {
+		sort.SliceStable(example.Steps, func(i, j int) bool {
+			return example.Steps[i].Total > example.Steps[j].Total
+		})
+	}
+	return result
+}
+
+// WriteTable writes the slowest and the flakiest steps of each example. top limits the number of steps in each table
+func WriteTable(w io.Writer, examples []*ExampleSummary, top int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, example := range examples {
+		_, _ = fmt.Fprintf(tw, "%v\ttotal %v\n", example.Example, example.Total.Round(time.Millisecond))
+
+		_, _ = fmt.Fprintln(tw, "  SLOWEST\tRUNS\tMEAN\tMAX\tTOTAL\tCOMMAND")
+		for _, step := range limit(example.Steps, top) {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\n", stepLocation(step), step.Runs,
+				step.Mean().Round(time.Millisecond), step.Max.Round(time.Millisecond), step.Total.Round(time.Millisecond), firstLine(step.Command))
+		}
+
+		var flaky []*StepSummary
+		for _, step := range example.Steps {
+			if step.Flaky() {
+				flaky = append(flaky, step)
+			}
+		}
+		sort.SliceStable(flaky, func(i, j int) bool {
+			return flaky[i].Failures+flaky[i].Retried > flaky[j].Failures+flaky[j].Retried
+		})
+		if len(flaky) > 0 {
+			_, _ = fmt.Fprintln(tw, "  FLAKIEST\tRUNS\tFAILED\tRETRIED\tATTEMPTS\tCOMMAND")
+			for _, step := range limit(flaky, top) {
+				_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\n", stepLocation(step), step.Runs,
+					step.Failures, step.Retried, step.Attempts, firstLine(step.Command))
+			}
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+	return errors.Wrap(tw.Flush(), "cannot write report table")
+}
+
+func limit(steps []*StepSummary, top int) []*StepSummary {
+	if top > 0 && len(steps) > top {
+		return steps[:top]
+	}
+	return steps
+}
+
+// exampleName returns the markdown file of the step or the suite if the location is unknown
+func exampleName(step *Step) string {
+	if i := strings.LastIndex(step.Location, ":"); i > 0 {
+		return step.Location[:i]
+	}
+	return step.Suite
+}
+
+func stepLocation(step *StepSummary) string {
+	if step.Location == "" {
+		return "-"
+	}
+	return step.Location
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+func TestAggregate(t *testing.T) {
+	step := func(location, command string, duration time.Duration, attempts, exitCode int) *report.Step {
+		return &report.Step{Suite: "TestEntryPoint", Location: location, Command: command, Duration: duration, Attempts: attempts, ExitCode: exitCode}
+	}
+	examples := report.Aggregate(
+		step("examples/A/README.md:10", "kubectl apply -k .", time.Second, 1, 0),
+		step("examples/B/README.md:5", "kubectl wait --for=condition=ready pod", 10*time.Second, 4, 0),
+		step("examples/A/README.md:20", "curl localhost", 3*time.Second, 1, 0),
+		step("examples/A/README.md:10", "kubectl apply -k .", 3*time.Second, 2, 0),
+		step("", "echo without location", time.Millisecond, 1, 1),
+	)
+	require.Len(t, examples, 3)
+	require.Equal(t, "examples/B/README.md", examples[0].Example)
+	require.Equal(t, "examples/A/README.md", examples[1].Example)
+	require.Equal(t, 7*time.Second, examples[1].Total)
+	require.Equal(t, "TestEntryPoint", examples[2].Example)
+
+	a := examples[1].Steps[0]
+	require.Equal(t, "examples/A/README.md:10", a.Location)
+	require.Equal(t, 2, a.Runs)
+	require.Equal(t, 1, a.Retried)
+	require.Equal(t, 3, a.Attempts)
+	require.Equal(t, 2*time.Second, a.Mean())
+	require.Equal(t, 3*time.Second, a.Max)
+	require.True(t, a.Flaky())
+	require.False(t, examples[1].Steps[1].Flaky())
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteTable(&buffer, examples, 1))
+	table := buffer.String()
+	require.True(t, strings.HasPrefix(table, "examples/B/README.md"), table)
+	require.Contains(t, table, "examples/A/README.md:10")
+	require.NotContains(t, table, "examples/A/README.md:20")
+	require.Contains(t, table, "FLAKIEST")
+	require.Contains(t, table, "echo without location")
+}
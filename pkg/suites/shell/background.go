@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+// Background starts the command as a background job and waits until the readiness probe succeeds. Empty probe isn't run.
+// The job is stopped when the test finishes, before cleanup steps registered earlier. Its output is logged and added to the report.
+func (r *Runner) Background(cmd, ready string) {
+	r.BackgroundAt("", cmd, ready)
+}
+
+// BackgroundAt starts the command like Background. The location of the step in the markdown example is added to the report
+func (r *Runner) BackgroundAt(location, cmd, ready string) {
+	step := &report.Step{
+		Suite:    suiteName(r.t.Name()),
+		Test:     r.t.Name(),
+		Command:  cmd,
+		Location: location,
+		Start:    time.Now(),
+		Attempts: 1,
+	}
+
+	output, err := os.CreateTemp("", "gotestmd-background-*.log")
+	if err != nil {
+		r.t.Fatalf("can't create output file for background job: %v", err)
+	}
+	_ = output.Close()
+
+	r.logger.WithField(r.t.Name(), "background").Info(cmd)
+	pid, err := r.bash.Start(cmd, output.Name())
+	if err != nil {
+		_ = os.Remove(output.Name())
+		r.t.Fatalf("can't start background job: %v", err)
+	}
+	r.cleanup(func() {
+		r.stopBackground(pid, step, output.Name())
+	})
+
+	if ready != "" {
+		r.waitReady(pid, ready, output.Name())
+	}
+}
+
+// waitReady runs the readiness probe until it succeeds. Fails the test if the job exits or the timeout passes
+func (r *Runner) waitReady(pid int, ready, output string) {
+	timeoutCh := time.After(r.timeout)
+	for {
+		r.logger.WithField(r.t.Name(), "ready").Info(ready)
+		_, _, exitCode, err := r.bash.Run(ready)
+		if err != nil {
+			r.t.Fatalf("can't run readiness probe: %v", err)
+		}
+		if exitCode == 0 {
+			return
+		}
+		if !r.bash.Running(pid) {
+			r.t.Fatalf("background job exited before it is ready, output:\n%v", r.masker.mask(readOutput(output)))
+		}
+		select {
+		case <-timeoutCh:
+			r.t.Fatalf("background job is not ready until timeout, output:\n%v", r.masker.mask(readOutput(output)))
+		default:
+			time.Sleep(r.interval)
+		}
+	}
+}
+
+// stopBackground stops the job, logs its output and adds it to the report
+func (r *Runner) stopBackground(pid int, step *report.Step, output string) {
+	r.bash.StopJob(pid)
+	step.Stdout = readOutput(output)
+	step.Duration = time.Since(step.Start)
+	step.History = []*report.Attempt{{Duration: step.Duration}}
+	_ = os.Remove(output)
+
+	if step.Stdout != "" {
+		r.logger.WithField(r.t.Name(), "background").Info(step.Stdout)
+	}
+	r.logger.WithField(r.t.Name(), "duration").Info(step.Duration)
+	if reporter := getReporter(); reporter != nil {
+		reporter.Add(r.masker.maskStep(step))
+	}
+}
+
+func readOutput(path string) string {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var artifactsFlag = flag.String("gotestmd.artifacts", "", "dir for outputs of diagnostics steps of failed tests. Each test has its own subdir")
+
+// OnFailure registers commands that are run if the test or any of its subtests fails.
+// Commands are run before undo and cleanup steps, outputs are logged and saved to the -gotestmd.artifacts dir.
+func (r *Runner) OnFailure(cmds ...string) {
+	r.diagnostics = append(r.diagnostics, cmds...)
+	r.registerTeardown()
+}
+
+func (r *Runner) runDiagnostics() {
+	dir := artifactsDir(r.t.Name())
+	for i, cmd := range r.diagnostics {
+		r.logger.WithField(r.t.Name(), "diagnostics").Info(cmd)
+		stdout, stderr, exitCode, err := r.bash.Run(cmd)
+		if err != nil {
+			r.logger.Errorf("can't run diagnostics command: %v", err)
+			return
+		}
+		if stdout != "" {
+			r.logger.WithField(r.t.Name(), "stdout").Info(stdout)
+		}
+		if stderr != "" {
+			r.logger.WithField(r.t.Name(), "stderr").Info(stderr)
+		}
+		if dir == "" {
+			continue
+		}
+		content := r.masker.mask(fmt.Sprintf("$ %v\n# exit code %v\n# stdout\n%v\n# stderr\n%v\n", cmd, exitCode, stdout, stderr))
+		if err := writeArtifact(dir, fmt.Sprintf("diagnostics-%v.log", i+1), content); err != nil {
+			r.logger.Errorf("can't save diagnostics: %v", err)
+		}
+	}
+}
+
+// artifactsDir returns a dir for artifacts of the test or empty string if -gotestmd.artifacts is not set
+func artifactsDir(testName string) string {
+	once.Do(func() {
+		flag.Parse()
+	})
+	if *artifactsFlag == "" {
+		return ""
+	}
+	var elems = []string{*artifactsFlag}
+	for _, name := range strings.Split(testName, "/") {
+		elems = append(elems, nameReplacer.Replace(name))
+	}
+	return filepath.Join(elems...)
+}
+
+var nameReplacer = strings.NewReplacer(`\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+func writeArtifact(dir, name, content string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+}
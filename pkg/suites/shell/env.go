@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var envLineRegex = regexp.MustCompile(`^(?:export\s+)?([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*(.*)$`)
+
+// LoadEnv sets env variables for the runners of the suite. The passed variables override variables from the env file,
+// variables set in the environment take precedence over both. A missing env file is ignored.
+// The file path is relative to the module root.
+func (s *Suite) LoadEnv(file string, env ...string) {
+	var result []string
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			root, err := findRoot()
+			if err != nil {
+				s.T().Fatalf("can't load env: %v", err)
+			}
+			file = filepath.Join(root, file)
+		}
+		fileEnv, err := readEnvFile(file)
+		if err != nil {
+			s.T().Fatalf("can't load env: %v", err)
+		}
+		result = append(result, fileEnv...)
+	}
+	result = append(result, env...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.env = result
+}
+
+// environ returns env variables of the suite that are not set in the environment
+func (s *Suite) environ() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []string
+	for _, kv := range s.env {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := os.LookupEnv(name); !ok {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+// lookupEnv returns true if the env variable is set in the environment or by LoadEnv
+func (s *Suite) lookupEnv(name string) bool {
+	if _, ok := os.LookupEnv(name); ok {
+		return true
+	}
+	for _, kv := range s.environ() {
+		if strings.HasPrefix(kv, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// readEnvFile reads NAME=value lines of the .env file. Empty lines and comments are skipped, quotes around values are removed
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open env file %v", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var result []string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		match := envLineRegex.FindStringSubmatch(text)
+		if match == nil {
+			return nil, errors.Errorf("invalid line %v of env file %v", line, path)
+		}
+		value := match[2]
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		result = append(result, match[1]+"="+value)
+	}
+	return result, errors.Wrapf(scanner.Err(), "cannot read env file %v", path)
+}
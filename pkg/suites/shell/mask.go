@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"flag"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/networkservicemesh/gotestmd/pkg/bash"
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+const (
+	maskedValue = "***"
+	// minMaskedLength is a length of the shortest masked value. Shorter values would mask unrelated parts of the output
+	minMaskedLength = 4
+)
+
+var maskEnvFlag = flag.String("gotestmd.mask-env", "", "comma-separated env variables whose values are masked in logs, reports and failure messages. Values shorter than 4 characters are not masked")
+var varNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var maskFlag = flag.String("gotestmd.mask", "", "regular expression whose matches are masked in logs, reports and failure messages, e.g. \"token: \\\\S+\"")
+
+// masker replaces secrets with *** in logs, reports and failure messages
+type masker struct {
+	mu      sync.Mutex
+	names   []string
+	pattern *regexp.Regexp
+	values  []string
+}
+
+// newMasker creates a masker configured by -gotestmd.mask-env and -gotestmd.mask flags
+func newMasker() (*masker, error) {
+	result := new(masker)
+	for _, name := range strings.Split(*maskEnvFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if !varNameRegex.MatchString(name) {
+				return nil, errors.Errorf("invalid -gotestmd.mask-env variable %v", name)
+			}
+			result.names = append(result.names, name)
+			result.add(os.Getenv(name))
+		}
+	}
+	if *maskFlag != "" {
+		pattern, err := regexp.Compile(*maskFlag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid -gotestmd.mask %v", *maskFlag)
+		}
+		result.pattern = pattern
+	}
+	return result, nil
+}
+
+// options returns bash options that read values of the masked env variables with each command
+func (m *masker) options() []bash.Option {
+	if len(m.names) == 0 {
+		return nil
+	}
+	return []bash.Option{bash.WithVars(m.names...)}
+}
+
+// learn adds values of the masked env variables read by the last command of the bash session, so values set by the steps are masked too
+func (m *masker) learn(b *bash.Bash) {
+	for _, value := range b.Vars() {
+		m.add(value)
+	}
+}
+
+// add adds the value and its lines to the masked values. Values shorter than minMaskedLength are skipped
+func (m *masker) add(value string) {
+	if value = strings.TrimSpace(value); value == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range append(strings.Split(value, "\n"), value) {
+		if v = strings.TrimSpace(v); len(v) >= minMaskedLength && !contains(m.values, v) {
+			m.values = append(m.values, v)
+		}
+	}
+	// longer values are masked first, so parts of them are not left unmasked
+	sort.Slice(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+}
+
+// mask returns s with masked values and matches of the pattern replaced by ***
+func (m *masker) mask(s string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.values {
+		s = strings.ReplaceAll(s, v, maskedValue)
+	}
+	if m.pattern != nil {
+		s = m.pattern.ReplaceAllString(s, maskedValue)
+	}
+	return s
+}
+
+// maskStep returns a copy of the step with masked command and outputs
+func (m *masker) maskStep(step *report.Step) *report.Step {
+	result := *step
+	result.Command, result.Stdout, result.Stderr = m.mask(step.Command), m.mask(step.Stdout), m.mask(step.Stderr)
+	result.History = nil
+	for _, a := range step.History {
+		masked := *a
+		masked.Stdout, masked.Stderr = m.mask(a.Stdout), m.mask(a.Stderr)
+		result.History = append(result.History, &masked)
+	}
+	return &result
+}
+
+// maskingFormatter masks secrets in formatted log entries
+type maskingFormatter struct {
+	logrus.Formatter
+	masker *masker
+}
+
+// Format formats the entry and masks secrets in the result
+func (f *maskingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	bytes, err := f.Formatter.Format(entry)
+	return []byte(f.masker.mask(string(bytes))), err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"flag"
+	"strings"
+	"sync"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+var reportJUnitFlag = flag.String("gotestmd.report.junit", "", "path of the JUnit XML report with executed steps")
+var reportJSONFlag = flag.String("gotestmd.report.json", "", "path of the JSON report with executed steps")
+
+var reporter *report.Reporter
+var reporterOnce sync.Once
+
+// getReporter returns the reporter configured by flags or nil if reports are disabled
+func getReporter() *report.Reporter {
+	reporterOnce.Do(func() {
+		once.Do(func() {
+			flag.Parse()
+		})
+		if *reportJUnitFlag == "" && *reportJSONFlag == "" {
+			return
+		}
+		reporter = report.New(report.WithJUnitFile(*reportJUnitFlag), report.WithJSONFile(*reportJSONFlag))
+	})
+	return reporter
+}
+
+// FlushReport writes reports passed via -gotestmd.report.junit and -gotestmd.report.json flags.
+// Reports are also flushed when the test that created a runner finishes.
+func FlushReport() error {
+	if r := getReporter(); r != nil {
+		return r.Flush()
+	}
+	return nil
+}
+
+// suiteName returns a name of the suite for the test name, e.g. TestEntryPoint/tree for TestEntryPoint/tree/TestLeafA
+func suiteName(testName string) string {
+	if i := strings.LastIndex(testName, "/"); i > 0 && strings.HasPrefix(testName[i+1:], "Test") {
+		return testName[:i]
+	}
+	return testName
+}
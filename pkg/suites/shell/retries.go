@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+)
+
+var maxAttemptsFlag = flag.Int("gotestmd.max-attempts", 0, "fails the test if any step needs more attempts to succeed. 0 means no limit")
+
+// retries collects steps of the suite that succeeded after retries
+type retries struct {
+	mu    sync.Mutex
+	steps []*report.Step
+}
+
+// retriesKey separates steps of a shared required suite from steps of the suite that has set it up
+type retriesKey struct {
+	t      *testing.T
+	shared *sharedSetup
+}
+
+var retriesMu sync.Mutex
+var retriesBySuite = map[retriesKey]*retries{}
+
+// retriesOf returns retries of the suite shared by its tests.
+// A warning with retried steps is logged once when the suite finishes or, for a shared required suite, when it is cleaned up
+func retriesOf(s *Suite, logger *logrus.Logger) *retries {
+	retriesMu.Lock()
+	defer retriesMu.Unlock()
+	s.mu.Lock()
+	t, shared := s.suiteT, s.shared
+	s.mu.Unlock()
+	if t == nil {
+		t = s.T()
+	}
+	key := retriesKey{t: t, shared: shared}
+	if result, ok := retriesBySuite[key]; ok {
+		return result
+	}
+	result := new(retries)
+	retriesBySuite[key] = result
+	name := t.Name()
+	if shared != nil {
+		name = shared.name
+	}
+	summary := func() {
+		retriesMu.Lock()
+		delete(retriesBySuite, key)
+		retriesMu.Unlock()
+		if summary := result.String(); summary != "" {
+			logger.WithField(name, "retries").Warnf("steps succeeded after retries:\n%v", summary)
+		}
+	}
+	if shared != nil {
+		sharedSetups.addCleanup(shared, summary)
+	} else {
+		t.Cleanup(summary)
+	}
+	return result
+}
+
+func (r *retries) add(step *report.Step) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *retries) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sb strings.Builder
+	for _, step := range r.steps {
+		_, _ = fmt.Fprintf(&sb, "%v (%v attempts): %v\n", stepLocation(step), step.Attempts, strings.SplitN(step.Command, "\n", 2)[0])
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func stepLocation(step *report.Step) string {
+	if step.Location == "" {
+		return step.Test
+	}
+	return step.Location
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// sharedSuite is a suite that can be set up once for all suites requiring it
+type sharedSuite interface {
+	suite.TestingSuite
+	share(setup *sharedSetup) *Suite
+}
+
+// sharedSetup is a required suite that is set up once and cleaned up when the last suite requiring it finishes
+type sharedSetup struct {
+	name string
+	// mu is held while the suite is being set up, so other suites requiring it wait for the setup
+	mu      sync.Mutex
+	started bool
+	ok      bool
+	refs    int
+	suite   *Suite
+	// cleanups and runners are guarded by the registry mutex
+	cleanups []func()
+	runners  []*Runner
+}
+
+// registry contains shared setups of the process
+type registry struct {
+	mu     sync.Mutex
+	holds  int
+	setups map[string]*sharedSetup
+	// done contains set up suites in the order their setup finished
+	done []*sharedSetup
+}
+
+var sharedSetups = &registry{setups: map[string]*sharedSetup{}}
+
+// ShareSetup keeps the required suites set up by the subtests of t until t finishes.
+// Without it, a required suite is cleaned up as soon as no running suite requires it,
+// so suites that are run one by one set it up again.
+func ShareSetup(t *testing.T) {
+	sharedSetups.hold()
+	t.Cleanup(func() {
+		sharedSetups.unhold(t)
+	})
+}
+
+// SetupRequired sets up the required suites. Each required suite is set up once per process and shared by the suites requiring it.
+// Cleanups of a required suite are run when the last suite requiring it finishes.
+func (s *Suite) SetupRequired(suites ...suite.TestingSuite) {
+	for _, required := range suites {
+		shared, ok := required.(sharedSuite)
+		if !ok {
+			s.T().Fatalf("required suite %T is not based on shell.Suite", required)
+		}
+		setup := sharedSetups.acquire(suiteKey(required))
+		s.Cleanup(func() {
+			sharedSetups.release(setup, s.T())
+		})
+		setup.setUp(s.T(), shared)
+	}
+}
+
+// share makes cleanups of the suite wait until the shared setup is released
+func (s *Suite) share(setup *sharedSetup) *Suite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shared = setup
+	return s
+}
+
+func (s *Suite) sharing() *sharedSetup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shared
+}
+
+func suiteKey(s suite.TestingSuite) string {
+	t := reflect.TypeOf(s)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// setUp sets up the suite if it is not set up yet. Fails the test if the setup has failed
+func (s *sharedSetup) setUp(t *testing.T, required sharedSuite) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.started = true
+		s.suite = required.share(s)
+		// the setup is finished even if it fails, so its cleanups are run
+		defer sharedSetups.finish(s)
+		required.SetT(t)
+		if v, ok := required.(suite.SetupAllSuite); ok {
+			v.SetupSuite()
+		}
+		s.ok = true
+		return
+	}
+	if !s.ok {
+		t.Fatalf("required suite %v failed to set up", s.name)
+	}
+}
+
+func (r *registry) hold() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.holds++
+}
+
+// unhold releases the hold and hands cleanups of the setups that are not required anymore to t
+func (r *registry) unhold(t *testing.T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.holds--
+	if r.holds > 0 {
+		return
+	}
+	// t runs cleanups in reverse order, so suites are cleaned up in reverse setup order
+	for _, setup := range append([]*sharedSetup(nil), r.done...) {
+		if setup.refs == 0 {
+			r.cleanup(setup, t)
+		}
+	}
+}
+
+func (r *registry) acquire(name string) *sharedSetup {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	setup, ok := r.setups[name]
+	if !ok {
+		setup = &sharedSetup{name: name}
+		r.setups[name] = setup
+	}
+	setup.refs++
+	return setup
+}
+
+func (r *registry) release(setup *sharedSetup, t *testing.T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	setup.refs--
+	if setup.refs > 0 || r.holds > 0 {
+		return
+	}
+	r.cleanup(setup, t)
+}
+
+func (r *registry) finish(setup *sharedSetup) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = append(r.done, setup)
+}
+
+func (r *registry) addCleanup(setup *sharedSetup, f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	setup.cleanups = append(setup.cleanups, f)
+}
+
+func (r *registry) addRunner(setup *sharedSetup, runner *Runner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	setup.runners = append(setup.runners, runner)
+}
+
+// cleanup removes the setup from the registry and registers its cleanups in t.
+// The suite and its runners are bound to t, so failed cleanup steps fail t.
+func (r *registry) cleanup(setup *sharedSetup, t *testing.T) {
+	delete(r.setups, setup.name)
+	for i, done := range r.done {
+		if done == setup {
+			r.done = append(r.done[:i], r.done[i+1:]...)
+			break
+		}
+	}
+	if setup.suite == nil {
+		return
+	}
+	setup.suite.share(nil)
+	setup.suite.SetT(t)
+	for _, runner := range setup.runners {
+		runner.t = t
+	}
+	for _, f := range setup.cleanups {
+		t.Cleanup(f)
+	}
+}
@@ -21,79 +21,246 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/networkservicemesh/gotestmd/pkg/bash"
+	"github.com/networkservicemesh/gotestmd/pkg/report"
+	"github.com/networkservicemesh/gotestmd/pkg/tags"
 )
 
-var timeoutFlag = flag.Duration("gotestmd.t", time.Minute, "timeout for command execution. Usage: set timeout in duratiom format via shell.timeout flag")
+const (
+	timeoutFlagName      = "gotestmd.t"
+	defaultRetryInterval = time.Millisecond * 100
+)
+
+var tagsFlag = flag.String("gotestmd.tags", "", "expression over labels of examples to run, e.g. \"calico && !ipv6\"")
+var excludeTagsFlag = flag.String("gotestmd.exclude-tags", "", "expression over labels of examples to skip")
+var timeoutFlag = flag.Duration(timeoutFlagName, time.Minute, "timeout for command execution. Usage: set timeout in duratiom format via shell.timeout flag")
 var once sync.Once
 
 // Suite is testify suite that provides a shell helper functions for each test.
 type Suite struct {
 	suite.Suite
+	mu     sync.Mutex
+	shared *sharedSetup
+	env    []string
+	// suiteT is the test running the suite. Suite-wide state, e.g. retried steps, is bound to it
+	suiteT *testing.T
 }
 
-// Runner creates runner and sets the passed dir and envs
+// T returns the current test
+func (s *Suite) T() *testing.T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Suite.T()
+}
+
+// SetT sets the current test. Parallel tests reset it concurrently when they finish, so it is guarded by a mutex
+func (s *Suite) SetT(t *testing.T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.suiteT == nil {
+		s.suiteT = t
+	}
+	s.Suite.SetT(t)
+}
+
+// Cleanup registers a function to be called when the suite finishes.
+// Cleanups of a required suite are called when the last suite requiring it finishes.
+func (s *Suite) Cleanup(f func()) {
+	if shared := s.sharing(); shared != nil {
+		sharedSetups.addCleanup(shared, f)
+		return
+	}
+	s.T().Cleanup(f)
+}
+
+// Parallel signals that the current test is to be run in parallel with other parallel tests.
+// The current test of the suite changes while the test is paused, so the test should use the returned suite instead.
+func (s *Suite) Parallel() *Suite {
+	t := s.T()
+	s.mu.Lock()
+	env, suiteT := s.env, s.suiteT
+	s.mu.Unlock()
+	t.Parallel()
+	result := &Suite{env: env, suiteT: suiteT}
+	result.SetT(t)
+	return result
+}
+
+// Runner creates runner and sets the passed dir and envs. If envs are passed, they replace envs of the environment and LoadEnv
 func (s *Suite) Runner(dir string, env ...string) *Runner {
 	result := &Runner{
-		t: s.T(),
+		t:       s.T(),
+		cleanup: s.Cleanup,
 	}
 	if !filepath.IsAbs(dir) {
-		dir = filepath.Join(findRoot(), dir)
+		root, err := findRoot()
+		if err != nil {
+			s.FailNowf("can't find module root", "%v", err)
+		}
+		dir = filepath.Join(root, dir)
+	}
+	once.Do(func() {
+		flag.Parse()
+	})
+	var err error
+	if result.masker, err = newMasker(); err != nil {
+		s.FailNowf("can't initialize masking", "%v", err)
+	}
+	envOption := bash.WithExtraEnv(s.environ())
+	if len(env) > 0 {
+		envOption = bash.WithEnv(env)
 	}
-	b, err := bash.New(bash.WithDir(dir), bash.WithEnv(env))
+	b, err := bash.New(append([]bash.Option{bash.WithDir(dir), envOption}, result.masker.options()...)...)
 	if err != nil {
 		s.FailNowf("can't initialize bash", "%v", err)
 	}
 	result.bash = b
+	if shared := s.sharing(); shared != nil {
+		sharedSetups.addRunner(shared, result)
+	}
 
-	s.T().Cleanup(func() {
+	s.Cleanup(func() {
 		result.bash.Close()
 	})
+	if reporter := getReporter(); reporter != nil {
+		// cleanups are executed in reverse order, so the report includes cleanup steps registered later
+		s.Cleanup(func() {
+			if err := reporter.Flush(); err != nil {
+				result.t.Errorf("can't write report: %v", err)
+			}
+		})
+	}
 	result.logger = &logrus.Logger{
 		Out:   os.Stderr,
 		Level: logrus.DebugLevel,
-		Formatter: &logrus.TextFormatter{
-			DisableQuote: true,
+		Formatter: &maskingFormatter{
+			Formatter: &logrus.TextFormatter{
+				DisableQuote: true,
+			},
+			masker: result.masker,
 		},
 	}
+	result.retries = retriesOf(s, result.logger)
+	result.timeout = *timeoutFlag
+	result.interval = defaultRetryInterval
+	return result
+}
+
+// RequireEnv skips the test if any of the env variables is not set in the environment or by LoadEnv
+func (s *Suite) RequireEnv(names ...string) {
+	var missing []string
+	for _, name := range names {
+		if !s.lookupEnv(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		s.T().Skipf("required env variables are not set: %v", strings.Join(missing, ", "))
+	}
+}
+
+// SkipUnlessLabels skips the test unless any of the label sets is selected by -gotestmd.tags and -gotestmd.exclude-tags flags
+func (s *Suite) SkipUnlessLabels(labelSets ...[]string) {
 	once.Do(func() {
 		flag.Parse()
 	})
-	return result
+	selector, err := tags.NewSelector(*tagsFlag, *excludeTagsFlag)
+	if err != nil {
+		s.T().Fatalf("invalid tags: %v", err)
+	}
+	if selector.IsEmpty() {
+		return
+	}
+	for _, labels := range labelSets {
+		if selector.Match(labels) {
+			return
+		}
+	}
+	s.T().Skipf("labels %v are not selected", labelSets)
 }
 
-func findRoot() string {
-	wd, err := os.Getwd()
+// SkipUnlessPlatform skips the test if the current GOOS or GOOS/GOARCH doesn't match any of the platforms
+func (s *Suite) SkipUnlessPlatform(platforms ...string) {
+	for _, platform := range platforms {
+		if platform == runtime.GOOS || platform == runtime.GOOS+"/"+runtime.GOARCH {
+			return
+		}
+	}
+	s.T().Skipf("platform %v/%v is not supported, expected one of %v", runtime.GOOS, runtime.GOARCH, platforms)
+}
+
+// findRoot returns the dir of the module that contains the working dir
+func findRoot() (string, error) {
+	currDir, err := os.Getwd()
 	if err != nil {
-		logrus.Fatal(err.Error())
+		return "", errors.Wrap(err, "cannot get working dir")
 	}
-	currDir := wd
-	for len(currDir) > 0 {
-		if err != nil {
-			logrus.Fatal(err.Error())
+	for {
+		if _, err := os.Stat(filepath.Join(currDir, "go.mod")); err == nil {
+			return currDir, nil
 		}
-		p := filepath.Clean(filepath.Join(currDir, "go.mod"))
-		if _, err := os.Open(p); err == nil {
-			return currDir
+		parent := filepath.Dir(currDir)
+		if parent == currDir {
+			return "", errors.New("go.mod is not found in the working dir and its parents")
 		}
-		currDir = filepath.Dir(currDir)
+		currDir = parent
 	}
-	return ""
+}
+
+func isFlagPassed(name string) bool {
+	var result bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			result = true
+		}
+	})
+	return result
 }
 
 // Runner is shell runner.
 type Runner struct {
-	t      *testing.T
-	logger *logrus.Logger
-	bash   *bash.Bash
+	t           *testing.T
+	cleanup     func(func())
+	logger      *logrus.Logger
+	masker      *masker
+	bash        *bash.Bash
+	retries     *retries
+	timeout     time.Duration
+	interval    time.Duration
+	attempts    int
+	teardown    sync.Once
+	diagnostics []string
+	undo        []*undoStep
+}
+
+// WithTimeout sets timeout for command execution.
+// The timeout passed via -gotestmd.t flag takes precedence.
+func (r *Runner) WithTimeout(timeout time.Duration) *Runner {
+	if !isFlagPassed(timeoutFlagName) {
+		r.timeout = timeout
+	}
+	return r
+}
+
+// WithRetry sets max attempts and an interval between attempts for command execution.
+// Zero attempts means retrying until the timeout passes.
+func (r *Runner) WithRetry(attempts int, interval time.Duration) *Runner {
+	r.attempts = attempts
+	if interval > 0 {
+		r.interval = interval
+	}
+	return r
 }
 
 // Dir returns the directory where current runner instance is located
@@ -101,19 +268,47 @@ func (r *Runner) Dir() string {
 	return r.bash.Dir()
 }
 
-// Run runs cmd, logs stdin, stdout, stderr
-// Tries to run cmd several times, until it succeeds or timeout passes.
+// Run runs cmd, logs stdin, stdout, stderr. Secrets configured by -gotestmd.mask-env and -gotestmd.mask flags are masked
+// Tries to run cmd several times, until it succeeds, timeout passes or attempts are exhausted.
 //
 // Fails the test if the command can't be run successfully.
 func (r *Runner) Run(cmd string) {
-	timeoutCh := time.After(*timeoutFlag)
-	for {
+	r.RunAt("", cmd)
+}
+
+// RunAt runs cmd like Run. The location of the step in the markdown example, e.g. examples/helloworld/README.md:10, is added to the report
+func (r *Runner) RunAt(location, cmd string) {
+	timeoutCh := time.After(r.timeout)
+	step := &report.Step{
+		Suite:    suiteName(r.t.Name()),
+		Test:     r.t.Name(),
+		Command:  cmd,
+		Location: location,
+		Start:    time.Now(),
+	}
+	reporter := getReporter()
+	defer func() {
+		step.Duration = time.Since(step.Start)
+		r.logger.WithField(r.t.Name(), "duration").Info(step.Duration)
+		if reporter != nil {
+			reporter.Add(r.masker.maskStep(step))
+		}
+	}()
+	for attempt := 1; ; attempt++ {
 		r.logger.WithField(r.t.Name(), "stdin").Info(cmd)
+		start := time.Now()
 		stdout, stderr, exitCode, err := r.bash.Run(cmd)
 		if err != nil {
 			r.logger.Fatalf("can't run command: %v", err)
 			r.t.FailNow()
 		}
+		r.masker.learn(r.bash)
+		step.Stdout, step.Stderr, step.ExitCode, step.Attempts = stdout, stderr, exitCode, attempt
+		a := &report.Attempt{ExitCode: exitCode, Duration: time.Since(start)}
+		if exitCode != 0 {
+			a.Stdout, a.Stderr = stdout, stderr
+		}
+		step.History = append(step.History, a)
 		if stdout != "" {
 			r.logger.WithField(r.t.Name(), "stdout").Info(stdout)
 		}
@@ -121,15 +316,31 @@ func (r *Runner) Run(cmd string) {
 			r.logger.WithField(r.t.Name(), "stderr").Info(stderr)
 		}
 		if exitCode == 0 {
+			r.checkRetries(step)
 			return
 		}
 		r.logger.WithField(r.t.Name(), "exitCode").Info(exitCode)
+		if r.attempts > 0 && attempt >= r.attempts {
+			r.logger.WithField("cmd", cmd).Errorf("command didn't succeed after %v attempts", attempt)
+			require.Equal(r.t, 0, exitCode)
+		}
 		select {
 		case <-timeoutCh:
 			r.logger.WithField("cmd", cmd).Error("command didn't succeed until timeout")
 			require.Equal(r.t, 0, exitCode)
 		default:
-			time.Sleep(time.Millisecond * 100)
+			time.Sleep(r.interval)
 		}
 	}
 }
+
+// checkRetries records the step if it needed retries and fails the test if the step exceeded -gotestmd.max-attempts
+func (r *Runner) checkRetries(step *report.Step) {
+	if !step.Retried() {
+		return
+	}
+	r.retries.add(r.masker.maskStep(step))
+	if *maxAttemptsFlag > 0 && step.Attempts > *maxAttemptsFlag {
+		r.t.Errorf("step %v succeeded after %v attempts, but -gotestmd.max-attempts is %v", stepLocation(step), step.Attempts, *maxAttemptsFlag)
+	}
+}
@@ -19,9 +19,12 @@
 package shell_test
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
@@ -69,3 +72,278 @@ fi`)
 	require.NoError(t, err)
 	require.Equal(t, "1\n11\n111\n", string(bytes))
 }
+
+func TestShellRetryAttempts(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+
+	suite := shell.Suite{}
+	suite.SetT(t)
+	r := suite.Runner(tempDir).WithRetry(3, time.Millisecond)
+
+	fileName := "TestShellRetryAttempts.file"
+
+	r.Run(`echo attempt >>` + fileName + ` && [[ $(wc -l <` + fileName + `) -ge 3 ]]`)
+	bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, fileName)))
+	require.NoError(t, err)
+	require.Equal(t, "attempt\nattempt\nattempt\n", string(bytes))
+}
+
+func TestShellSkipUnlessLabels(t *testing.T) {
+	require.NoError(t, flag.Set("gotestmd.tags", "calico && !ipv6"))
+	t.Cleanup(func() { _ = flag.Set("gotestmd.tags", "") })
+
+	for labels, skipped := range map[string]bool{
+		"calico":      false,
+		"calico,ipv6": true,
+		"heal":        true,
+	} {
+		var sub *testing.T
+		t.Run(labels, func(t *testing.T) {
+			sub = t
+			suite := shell.Suite{}
+			suite.SetT(t)
+			suite.SkipUnlessLabels([]string{"other"}, strings.Split(labels, ","))
+		})
+		require.Equal(t, skipped, sub.Skipped(), labels)
+	}
+}
+
+func TestShellUndo(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	fileName := "TestShellUndo.file"
+
+	t.Run("steps", func(t *testing.T) {
+		suite := shell.Suite{}
+		suite.SetT(t)
+		r := suite.Runner(tempDir)
+		t.Cleanup(func() {
+			r.Run("echo cleanup >>" + fileName)
+		})
+		r.Run("echo step1 >>" + fileName)
+		r.Undo("echo undo1 >>" + fileName)
+		r.Run("echo step2 >>" + fileName)
+		r.Undo("echo undo2 >>" + fileName)
+	})
+
+	bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, fileName)))
+	require.NoError(t, err)
+	require.Equal(t, "step1\nstep2\nundo2\nundo1\ncleanup\n", string(bytes))
+}
+
+func TestShellParallel(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	suite := shell.Suite{}
+
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			name := name
+			t.Run(name, func(t *testing.T) {
+				suite.SetT(t)
+				s := suite.Parallel()
+				// the suite is set to the next test while this one is paused
+				require.Same(t, t, s.T())
+				s.Runner(tempDir).Run("echo " + name + " >" + name)
+			})
+		}
+	})
+
+	for _, name := range []string{"first", "second"} {
+		bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, name)))
+		require.NoError(t, err)
+		require.Equal(t, name+"\n", string(bytes))
+	}
+}
+
+type requiredSuite struct {
+	shell.Suite
+	dir string
+}
+
+func (s *requiredSuite) SetupSuite() {
+	r := s.Runner(s.dir)
+	s.Cleanup(func() {
+		r.Run("echo cleanup >>required.file")
+	})
+	r.Run("echo setup >>required.file")
+}
+
+func TestShellSetupRequired(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	fileName := filepath.Join(tempDir, "required.file")
+	requireFile := func(t *testing.T, expected string) {
+		bytes, err := os.ReadFile(filepath.Clean(fileName))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(bytes))
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		shell.ShareSetup(t)
+		for _, name := range []string{"first", "second"} {
+			t.Run(name, func(t *testing.T) {
+				suite := shell.Suite{}
+				suite.SetT(t)
+				suite.SetupRequired(&requiredSuite{dir: tempDir})
+				requireFile(t, "setup\n")
+			})
+		}
+	})
+	requireFile(t, "setup\ncleanup\n")
+
+	// without ShareSetup the required suite is cleaned up when the last suite requiring it finishes
+	require.NoError(t, os.Remove(fileName))
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			suite := shell.Suite{}
+			suite.SetT(t)
+			suite.SetupRequired(&requiredSuite{dir: tempDir})
+		})
+	}
+	requireFile(t, "setup\ncleanup\nsetup\ncleanup\n")
+}
+
+func TestShellBackground(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	ticksFile := filepath.Clean(filepath.Join(tempDir, "ticks"))
+
+	t.Run("job", func(t *testing.T) {
+		suite := shell.Suite{}
+		suite.SetT(t)
+		r := suite.Runner(tempDir)
+		r.Run("TICK=tick")
+		r.Background("sleep 0.2; touch ready; while true; do echo $TICK >>ticks; sleep 0.01; done", "[ -f ready ]")
+		_, err := os.Stat(filepath.Join(tempDir, "ready"))
+		require.NoError(t, err)
+	})
+
+	// the job is stopped when the test finishes
+	bytes, err := os.ReadFile(ticksFile)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	stopped, err := os.ReadFile(ticksFile)
+	require.NoError(t, err)
+	require.Equal(t, string(bytes), string(stopped))
+	require.Contains(t, string(bytes), "tick\n")
+}
+
+func TestShellEnv(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	envFile := filepath.Join(tempDir, ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("# comment\n\nFILE=file\nexport QUOTED='quoted value'\n"), 0o600))
+	t.Setenv("PROCESS", "process")
+
+	suite := shell.Suite{}
+	suite.SetT(t)
+	suite.LoadEnv(envFile, "FILE=front-matter", "FRONT_MATTER=front-matter", "PROCESS=front-matter")
+	suite.RequireEnv("FILE", "FRONT_MATTER", "PROCESS")
+	suite.Runner(tempDir).Run("echo $FILE $QUOTED $FRONT_MATTER $PROCESS >env.file")
+
+	bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, "env.file")))
+	require.NoError(t, err)
+	// the passed env overrides the env file, the environment overrides both
+	require.Equal(t, "front-matter quoted value front-matter process\n", string(bytes))
+
+	// the env passed to the runner replaces the whole env
+	suite.Runner(tempDir, "PASSED=passed").Run("echo ${PASSED-unset} ${FRONT_MATTER-unset} ${PROCESS-unset} >env.file")
+	bytes, err = os.ReadFile(filepath.Clean(filepath.Join(tempDir, "env.file")))
+	require.NoError(t, err)
+	require.Equal(t, "passed unset unset\n", string(bytes))
+
+	var sub *testing.T
+	t.Run("missing", func(t *testing.T) {
+		sub = t
+		suite := shell.Suite{}
+		suite.SetT(t)
+		suite.LoadEnv(filepath.Join(tempDir, "missing.env"))
+		suite.RequireEnv("PROCESS", "GOTESTMD_MISSING")
+	})
+	require.True(t, sub.Skipped())
+}
+
+func TestShellMask(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	require.NoError(t, flag.Set("gotestmd.mask-env", "GOTESTMD_TOKEN,SESSION_TOKEN,SHORT_TOKEN"))
+	require.NoError(t, flag.Set("gotestmd.mask", `password=\S+`))
+	t.Cleanup(func() {
+		_ = flag.Set("gotestmd.mask-env", "")
+		_ = flag.Set("gotestmd.mask", "")
+	})
+	t.Setenv("GOTESTMD_TOKEN", "process-secret")
+
+	tempDir := t.TempDir()
+	logFile, err := os.Create(filepath.Clean(filepath.Join(tempDir, "log")))
+	require.NoError(t, err)
+	defer func() { _ = logFile.Close() }()
+
+	t.Run("steps", func(t *testing.T) {
+		// the runner logs to stderr
+		stderr := os.Stderr
+		os.Stderr = logFile
+		suite := shell.Suite{}
+		suite.SetT(t)
+		r := suite.Runner(tempDir)
+		os.Stderr = stderr
+
+		r.Run("echo $GOTESTMD_TOKEN")
+		// the value is set by the step, so it is masked only in the next steps
+		r.Run("SESSION_TOKEN=$(echo c2Vzc2lvbi1zZWNyZXQ= | base64 -d)")
+		r.Run("echo token $SESSION_TOKEN password=hunter2")
+		// short values would mask unrelated output
+		r.Run("SHORT_TOKEN=abc")
+		r.Run("echo short abc")
+	})
+
+	bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, "log")))
+	require.NoError(t, err)
+	require.Contains(t, string(bytes), "msg=*** TestShellMask/steps=stdout")
+	require.Contains(t, string(bytes), "msg=token *** *** TestShellMask/steps=stdout")
+	require.Contains(t, string(bytes), "msg=short abc TestShellMask/steps=stdout")
+	for _, secret := range []string{"process-secret", "session-secret", "hunter2"} {
+		require.NotContains(t, string(bytes), secret)
+	}
+}
+
+func TestShellRetriesSummary(t *testing.T) {
+	t.Cleanup(func() { goleak.VerifyNone(t) })
+
+	tempDir := t.TempDir()
+	logFile, err := os.Create(filepath.Clean(filepath.Join(tempDir, "log")))
+	require.NoError(t, err)
+	defer func() { _ = logFile.Close() }()
+
+	t.Run("suite", func(t *testing.T) {
+		suite := shell.Suite{}
+		suite.SetT(t)
+		for _, name := range []string{"first", "second"} {
+			name := name
+			t.Run(name, func(t *testing.T) {
+				suite.SetT(t)
+				// the runner logs to stderr
+				stderr := os.Stderr
+				os.Stderr = logFile
+				r := suite.Runner(tempDir)
+				os.Stderr = stderr
+
+				r.Run("[ -f " + name + ".flag ] || { touch " + name + ".flag; false; }")
+			})
+		}
+	})
+
+	bytes, err := os.ReadFile(filepath.Clean(filepath.Join(tempDir, "log")))
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(bytes), "steps succeeded after retries"))
+	require.Contains(t, string(bytes), "TestShellRetriesSummary/suite/first (2 attempts): [ -f first.flag ]")
+	require.Contains(t, string(bytes), "TestShellRetriesSummary/suite/second (2 attempts): [ -f second.flag ]")
+}
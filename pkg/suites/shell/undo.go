@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shell
+
+// Undo registers the command that undoes the last successful step.
+// Undo commands are run in reverse order when the test finishes, before cleanup steps registered earlier.
+func (r *Runner) Undo(cmd string) {
+	r.UndoAt("", cmd)
+}
+
+// UndoAt registers the undo command like Undo. The location of the command in the markdown example is added to the report
+func (r *Runner) UndoAt(location, cmd string) {
+	r.undo = append(r.undo, &undoStep{location: location, cmd: cmd})
+	r.registerTeardown()
+}
+
+type undoStep struct {
+	location string
+	cmd      string
+}
+
+// registerTeardown registers a cleanup that runs diagnostics if the test fails and then undo commands
+func (r *Runner) registerTeardown() {
+	r.teardown.Do(func() {
+		r.cleanup(func() {
+			if r.t.Failed() {
+				r.runDiagnostics()
+			}
+			// cleanups registered during cleanup are run next in reverse order, each of them is run even if others fail
+			for _, step := range r.undo {
+				step := step
+				r.cleanup(func() {
+					r.RunAt(step.location, step.cmd)
+				})
+			}
+		})
+	})
+}
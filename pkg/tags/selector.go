@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tags
+
+// Selector selects labels matching the include expression and not matching the exclude expression
+type Selector struct {
+	include Expr
+	exclude Expr
+}
+
+// NewSelector creates a Selector from include and exclude expressions. Empty expressions are ignored
+func NewSelector(include, exclude string) (*Selector, error) {
+	var s = new(Selector)
+	var err error
+	if include != "" {
+		if s.include, err = Parse(include); err != nil {
+			return nil, err
+		}
+	}
+	if exclude != "" {
+		if s.exclude, err = Parse(exclude); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// IsEmpty returns true if the selector selects everything
+func (s *Selector) IsEmpty() bool {
+	return s.include == nil && s.exclude == nil
+}
+
+// Match returns true if the labels are selected
+func (s *Selector) Match(labels []string) bool {
+	if s.include != nil && !s.include.Eval(labels) {
+		return false
+	}
+	return s.exclude == nil || !s.exclude.Eval(labels)
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tags provides boolean expressions over labels of examples, e.g. "calico && !ipv6"
+package tags
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var labelRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// IsValidLabel returns true if the label can be used in expressions
+func IsValidLabel(label string) bool {
+	return labelRegex.MatchString(label)
+}
+
+// Expr is a boolean expression over labels
+type Expr interface {
+	// Eval returns true if the labels satisfy the expression
+	Eval(labels []string) bool
+	String() string
+}
+
+// Parse parses an expression of labels combined with &&, ||, ! and parentheses
+func Parse(s string) (Expr, error) {
+	p := &exprParser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, errors.Errorf("empty tags expression")
+	}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse tags expression %q", s)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.Errorf("cannot parse tags expression %q: unexpected %q", s, p.tokens[p.pos])
+	}
+	return result, nil
+}
+
+type labelExpr string
+
+func (e labelExpr) Eval(labels []string) bool {
+	for _, l := range labels {
+		if l == string(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e labelExpr) String() string {
+	return string(e)
+}
+
+type notExpr struct {
+	x Expr
+}
+
+func (e *notExpr) Eval(labels []string) bool {
+	return !e.x.Eval(labels)
+}
+
+func (e *notExpr) String() string {
+	return "!" + e.x.String()
+}
+
+type andExpr struct {
+	x, y Expr
+}
+
+func (e *andExpr) Eval(labels []string) bool {
+	return e.x.Eval(labels) && e.y.Eval(labels)
+}
+
+func (e *andExpr) String() string {
+	return "(" + e.x.String() + " && " + e.y.String() + ")"
+}
+
+type orExpr struct {
+	x, y Expr
+}
+
+func (e *orExpr) Eval(labels []string) bool {
+	return e.x.Eval(labels) || e.y.Eval(labels)
+}
+
+func (e *orExpr) String() string {
+	return "(" + e.x.String() + " || " + e.y.String() + ")"
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &orExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &andExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseNot() (Expr, error) {
+	switch token := p.peek(); token {
+	case "":
+		return nil, errors.New("unexpected end of expression")
+	case "!":
+		p.pos++
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	case "(":
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return x, nil
+	default:
+		if !IsValidLabel(token) {
+			return nil, errors.Errorf("unexpected %q", token)
+		}
+		p.pos++
+		return labelExpr(token), nil
+	}
+}
+
+func tokenize(s string) []string {
+	var result []string
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == ' ' || s[i] == '\t':
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			result = append(result, s[i:i+2])
+			i += 2
+		case s[i] == '!' || s[i] == '(' || s[i] == ')':
+			result = append(result, s[i:i+1])
+			i++
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t&|!()", rune(s[j])) {
+				j++
+			}
+			if j == i {
+				// a single & or |
+				j++
+			}
+			result = append(result, s[i:j])
+			i = j
+		}
+	}
+	return result
+}
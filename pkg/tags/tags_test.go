@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tags_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/gotestmd/pkg/tags"
+)
+
+func TestParse(t *testing.T) {
+	for expr, expected := range map[string]string{
+		"calico":                      "calico",
+		"calico && !ipv6":             "(calico && !ipv6)",
+		"a || b && c":                 "(a || (b && c))",
+		"(a || b) && c":               "((a || b) && c)",
+		"!!heal":                      "!!heal",
+		"interdomain&&(heal||k8s.io)": "(interdomain && (heal || k8s.io))",
+	} {
+		e, err := tags.Parse(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, expected, e.String())
+	}
+
+	for _, expr := range []string{"", "a &&", "(a", "a b", "a & b", "a)", "!", "a || $b"} {
+		_, err := tags.Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestEval(t *testing.T) {
+	e, err := tags.Parse("calico && !ipv6 || heal")
+	require.NoError(t, err)
+
+	require.True(t, e.Eval([]string{"calico"}))
+	require.False(t, e.Eval([]string{"calico", "ipv6"}))
+	require.True(t, e.Eval([]string{"calico", "ipv6", "heal"}))
+	require.False(t, e.Eval(nil))
+}
+
+func TestSelector(t *testing.T) {
+	s, err := tags.NewSelector("", "")
+	require.NoError(t, err)
+	require.True(t, s.IsEmpty())
+	require.True(t, s.Match(nil))
+
+	s, err = tags.NewSelector("calico", "ipv6")
+	require.NoError(t, err)
+	require.False(t, s.IsEmpty())
+	require.True(t, s.Match([]string{"calico"}))
+	require.False(t, s.Match([]string{"calico", "ipv6"}))
+	require.False(t, s.Match([]string{"heal"}))
+
+	_, err = tags.NewSelector("calico &&", "")
+	require.Error(t, err)
+}